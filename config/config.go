@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"github.com/spf13/viper"
 	"os"
 	"path"
@@ -17,9 +18,25 @@ type FileInformation struct {
 type Config struct {
 	DB DatabaseConfig `mapstructure:"DATABASE"`
 	Runtime RuntimeConfig `mapstructure:"RUNTIME"`
+	// ExcelTemplate describes where the column template lives; unset fields fall back
+	// to the historical db-template.xlsx / Sheet1 / A3:K3 / line 3 layout.
+	ExcelTemplate ExcelTemplateConfig `mapstructure:"EXCEL_TEMPLATE"`
 	SupportedVcsConfig []string
 }
 
+// ExcelTemplateConfig locates the header row of the Excel column template used to
+// derive the SQL column list.
+type ExcelTemplateConfig struct {
+	// FilePath is the path of the template workbook.
+	FilePath string `mapstructure:"FILE_PATH"`
+	// SheetName is the sheet holding the header row.
+	SheetName string `mapstructure:"SHEET_NAME"`
+	// HeaderRange is the cell range covering the column names (e.g. "A3:K3").
+	HeaderRange string `mapstructure:"HEADER_RANGE"`
+	// HeaderLine is the 1-based row number of the header within the sheet.
+	HeaderLine int `mapstructure:"HEADER_LINE"`
+}
+
 type DatabaseConfig struct {
 	DBDriver string `mapstructure:"DB_DRIVER"`
 	DBUser   string `mapstructure:"DB_USER"`
@@ -27,11 +44,227 @@ type DatabaseConfig struct {
 	DBHostname string `mapstructure:"DB_HOSTNAME"`
 	DBPort 	   string `mapstructure:"DB_PORT"`
 	DBName	   string `mapstructure:"DB_NAME"`
+	// DBPasswordFile is a path to a file holding the database password (e.g. a
+	// Kubernetes/Docker secret mount). When set, its contents (whitespace-trimmed)
+	// take precedence over DB_PASSWORD.
+	DBPasswordFile string `mapstructure:"DB_PASSWORD_FILE"`
+	// DBMaxOpenConns caps the connection pool size. Falls back to RUNTIME.WORKER_COUNT
+	// when zero so existing deployments keep their current behavior.
+	DBMaxOpenConns int `mapstructure:"DB_MAX_OPEN_CONNS"`
+	// DBMaxIdleConns caps idle connections kept in the pool. Zero keeps the driver default.
+	DBMaxIdleConns int `mapstructure:"DB_MAX_IDLE_CONNS"`
+	// DBConnMaxLifetimeSeconds recycles connections older than this many seconds.
+	// Zero keeps connections indefinitely.
+	DBConnMaxLifetimeSeconds int `mapstructure:"DB_CONN_MAX_LIFETIME_SECONDS"`
+}
+
+// String renders the database config with the password redacted, so logging the
+// config (e.g. via zap.Any, which honors fmt.Stringer) cannot leak credentials.
+func (d DatabaseConfig) String() string {
+	password := d.DBPassword
+	if password != "" {
+		password = "****"
+	}
+	return fmt.Sprintf("driver=%s user=%s password=%s hostname=%s port=%s dbname=%s",
+		d.DBDriver, d.DBUser, password, d.DBHostname, d.DBPort, d.DBName)
 }
 
 type RuntimeConfig struct {
+	// WorkerCount is the number of insert workers. Zero or negative defaults to the
+	// machine's CPU count (see ApplyDefaults), clamped to MaxWorkerCount.
 	WorkerCount int `mapstructure:"WORKER_COUNT"`
+	// MaxWorkerCount caps WorkerCount (including the CPU-count default) so a config
+	// typo or a large machine cannot overwhelm the database. Zero means no cap.
+	MaxWorkerCount int `mapstructure:"MAX_WORKER_COUNT"`
 	FileDestination string `mapstructure:"FILE_DESTINATION"`
+	// FileDisposition controls what happens to an input file after a successful
+	// ingest: "move" (default) archives it to FileDestination, "delete" removes it,
+	// "none" leaves it in place for a separate archival job. The CLI -disposition
+	// flag overrides it.
+	FileDisposition string `mapstructure:"FILE_DISPOSITION"`
+	// FailureDestination is where files that fail ingestion are quarantined, along
+	// with an .error.json sidecar describing the failure. It must differ from
+	// FileDestination. Empty leaves failed files in place. The CLI -failure-dir flag
+	// overrides it.
+	FailureDestination string `mapstructure:"FAILURE_DESTINATION"`
+	// AuditLogFile is the base path of the JSON Lines audit log recording per-file
+	// outcomes. Empty disables audit logging. The file is rotated daily by appending
+	// a date suffix to this path.
+	AuditLogFile string `mapstructure:"AUDIT_LOG_FILE"`
+	// ManifestFile is the path of the JSON Lines run manifest recording, for each
+	// processed file, its size, checksum, record counts, duration, archived path,
+	// and run ID. Empty disables the manifest. Query it with -show-manifest.
+	ManifestFile string `mapstructure:"MANIFEST_FILE"`
+	// SchemaSource selects where the column list comes from: "excel" (default, the
+	// db-template.xlsx workbook) or "db" (PostgreSQL information_schema).
+	SchemaSource string `mapstructure:"SCHEMA_SOURCE"`
+	// Filters lists record filter expressions (e.g. "status=Submitted") applied to each
+	// flattened record before insertion; records failing any filter are skipped and
+	// counted separately. CLI -filter flags are appended to this list.
+	Filters []string `mapstructure:"FILTERS"`
+	// Transforms lists record transformation specs (e.g. "rename:dtCreated=dt_created",
+	// "epoch-to-iso:dt_created") run, in order, after flattening and before required-column
+	// validation. CLI -transform flags are appended to this list.
+	Transforms []string `mapstructure:"TRANSFORMS"`
+	// RequiredColumns lists columns that must be present in every flattened record.
+	// Records missing any of them are rejected before insertion.
+	RequiredColumns []string `mapstructure:"REQUIRED_COLUMNS"`
+	// SkipDuplicates appends ON CONFLICT DO NOTHING to inserts so redelivered records
+	// are silently skipped instead of failing; skipped rows are counted separately.
+	SkipDuplicates bool `mapstructure:"SKIP_DUPLICATES"`
+	// ContinueOnFailure keeps a multi-file run going when one file fails instead of
+	// aborting; failed files are reported in the run summary.
+	ContinueOnFailure bool `mapstructure:"CONTINUE_ON_FAILURE"`
+	// MaxOpenTransactions bounds how many worker transactions may be open at once.
+	// When > 0 the streaming reducer commits batches as workers finish instead of
+	// holding every transaction until the end of the run. Zero keeps the collect-all
+	// reduce behavior.
+	MaxOpenTransactions int `mapstructure:"MAX_OPEN_TRANSACTIONS"`
+	// InsertRateLimit caps aggregate insert throughput across all workers, in
+	// records per second, so bulk loads cannot saturate a shared database. Zero
+	// means unlimited. The CLI -rate-limit flag overrides it.
+	InsertRateLimit float64 `mapstructure:"INSERT_RATE_LIMIT"`
+	// MaxFileSizeBytes rejects input files larger than this many bytes before any
+	// decoding starts, protecting shared infrastructure from runaway loads. Zero
+	// means unlimited. The CLI -max-file-size flag (human-readable, e.g. "500MB")
+	// overrides it.
+	MaxFileSizeBytes int64 `mapstructure:"MAX_FILE_SIZE_BYTES"`
+	// BatchTimeoutSeconds bounds each worker's map call: a call exceeding it (lock
+	// contention, runaway query) fails its batch, which is rolled back, instead of
+	// hanging the worker indefinitely. Zero disables the bound.
+	BatchTimeoutSeconds int `mapstructure:"BATCH_TIMEOUT_SECONDS"`
+	// RecordChanBuffer, TaskChanBuffer, and ResultChanBuffer size the streaming
+	// pipeline's channels (loader -> workers -> reducer). Larger buffers use more
+	// memory but reduce goroutine blocking. Zero takes the defaults: 1000 records,
+	// 1000 tasks, and WORKER_COUNT*2 results.
+	RecordChanBuffer int `mapstructure:"RECORD_CHAN_BUFFER"`
+	TaskChanBuffer   int `mapstructure:"TASK_CHAN_BUFFER"`
+	ResultChanBuffer int `mapstructure:"RESULT_CHAN_BUFFER"`
+	// TxIsolation selects the isolation level for worker transactions. Accepts
+	// "read-committed", "repeatable-read", or "serializable"; empty uses the
+	// database default.
+	TxIsolation string `mapstructure:"TX_ISOLATION"`
+	// ExpectedChecksum, when set, is the digest every input file must hash to before
+	// it is decoded; a mismatch fails the load. The CLI -checksum flag overrides it
+	// per run. Most deployments use checksum sidecars instead.
+	ExpectedChecksum string `mapstructure:"EXPECTED_CHECKSUM"`
+	// ChecksumAlgorithm names the hash used with EXPECTED_CHECKSUM: "md5", "sha256",
+	// or "sha512". Empty defaults to "sha256".
+	ChecksumAlgorithm string `mapstructure:"CHECKSUM_ALGORITHM"`
+	// ChecksumSidecars lists sidecar extensions probed next to each input file
+	// (e.g. [".sha256", ".md5"]); empty uses .sha256 then .md5.
+	ChecksumSidecars []string `mapstructure:"CHECKSUM_SIDECARS"`
+	// ChecksumRequired fails files that have no checksum sidecar instead of just
+	// warning, for pipelines where unverified input is unacceptable.
+	ChecksumRequired bool `mapstructure:"CHECKSUM_REQUIRED"`
+	// WriteChecksumSidecar writes the verified digest next to the archived copy so
+	// downstream consumers can re-verify it.
+	WriteChecksumSidecar bool `mapstructure:"WRITE_CHECKSUM_SIDECAR"`
+	// LockStaleAfterSeconds is how old another run's file claim may be before it is
+	// treated as abandoned and reclaimed; <= 0 uses a one-hour default.
+	LockStaleAfterSeconds int `mapstructure:"LOCK_STALE_AFTER_SECONDS"`
+	// ArchiveDatePartition archives files under a date subdirectory of the
+	// destination (e.g. 2024/06/15/) so re-delivered names from different days
+	// cannot collide.
+	ArchiveDatePartition bool `mapstructure:"ARCHIVE_DATE_PARTITION"`
+	// ArchiveTimestampSuffix appends a timestamp to the archived filename
+	// (data-20240615T120000.xml) so repeated deliveries on the same day are kept.
+	ArchiveTimestampSuffix bool `mapstructure:"ARCHIVE_TIMESTAMP_SUFFIX"`
+	// ArchiveNamingStrategy renames files while archiving them: "original" (default)
+	// keeps the name, "timestamp" appends _20240115T143022Z (UTC), "hash" appends
+	// the first 12 hex characters of the content's SHA-256. The CLI -archive-naming
+	// flag overrides it.
+	ArchiveNamingStrategy string `mapstructure:"ARCHIVE_NAMING_STRATEGY"`
+	// ArchiveCollisionPolicy controls what happens when the archived path already
+	// exists: "suffix" (default, alias "rename") appends a numeric suffix so no data
+	// is ever lost, "overwrite" replaces the existing copy, "error" (alias "fail")
+	// fails the move.
+	ArchiveCollisionPolicy string `mapstructure:"ARCHIVE_COLLISION_POLICY"`
+	// ArchiveCompress gzips files while archiving them (data.xml -> data.xml.gz) to
+	// save space on the archive volume.
+	ArchiveCompress bool `mapstructure:"ARCHIVE_COMPRESS"`
+}
+
+// ApplyDefaults fills derivable runtime settings: an unset or non-positive
+// WORKER_COUNT becomes the machine's CPU count, clamped to MAX_WORKER_COUNT, so a
+// missing value can never leave the pipeline with zero workers. Call it before
+// Validate.
+func (c *Config) ApplyDefaults() {
+	if c.Runtime.WorkerCount <= 0 {
+		c.Runtime.WorkerCount = runtime.NumCPU()
+	}
+	if c.Runtime.MaxWorkerCount > 0 && c.Runtime.WorkerCount > c.Runtime.MaxWorkerCount {
+		c.Runtime.WorkerCount = c.Runtime.MaxWorkerCount
+	}
+
+}
+
+// ResolveDBPassword applies the password source precedence: a secret file named
+// by DB_PASSWORD_FILE (config or environment) wins over the DB_PASSWORD
+// environment variable, which wins over the inline config value. The password
+// should come from a mount or the environment rather than a committed config file.
+func (c *Config) ResolveDBPassword() error {
+	passwordFile := c.DB.DBPasswordFile
+	if passwordFile == "" {
+		passwordFile = os.Getenv("DB_PASSWORD_FILE")
+	}
+	if passwordFile != "" {
+		raw, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return fmt.Errorf("failed to read DB_PASSWORD_FILE %s: %w", passwordFile, err)
+		}
+		c.DB.DBPassword = strings.TrimSpace(string(raw))
+		return nil
+	}
+	if envPassword := os.Getenv("DB_PASSWORD"); envPassword != "" {
+		c.DB.DBPassword = envPassword
+	}
+	return nil
+}
+
+// Validate checks the parsed config for the fields every ingest run depends on,
+// collecting every problem into one error so operators can fix them in a single
+// pass instead of chasing confusing downstream failures (a zero WORKER_COUNT, for
+// example, spawns no workers and hangs the pipeline).
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.Runtime.WorkerCount <= 0 {
+		problems = append(problems, "RUNTIME.WORKER_COUNT must be greater than zero")
+	}
+	if c.Runtime.FileDestination == "" {
+		problems = append(problems, "RUNTIME.FILE_DESTINATION must be set")
+	}
+
+	if c.DB.DBName == "" {
+		problems = append(problems, "DATABASE.DB_NAME must be set")
+	}
+	// Reject unknown drivers up front so a typo fails with a clear message
+	// instead of an unhelpful sql.Open error
+	driver := strings.ToLower(c.DB.DBDriver)
+	switch driver {
+	case "", "pgx", "postgres", "mysql", "mariadb", "sqlite", "sqlite3", "oracle", "godror":
+	default:
+		problems = append(problems, fmt.Sprintf(
+			"DATABASE.DB_DRIVER %q is not supported (expected pgx, postgres, mysql, mariadb, sqlite, or oracle)", c.DB.DBDriver))
+	}
+	// SQLite needs only a database file; the network drivers need a full endpoint
+	if driver != "sqlite" && driver != "sqlite3" {
+		if c.DB.DBUser == "" {
+			problems = append(problems, "DATABASE.DB_USER must be set")
+		}
+		if c.DB.DBHostname == "" {
+			problems = append(problems, "DATABASE.DB_HOSTNAME must be set")
+		}
+		if c.DB.DBPort == "" {
+			problems = append(problems, "DATABASE.DB_PORT must be set")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
 }
 
 // LoadConfig config file from given path
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// validConfig builds the smallest config Validate accepts for a network driver.
+func validConfig() *Config {
+	return &Config{
+		Runtime: RuntimeConfig{
+			WorkerCount:     2,
+			FileDestination: "/mnt/efs/",
+		},
+		DB: DatabaseConfig{
+			DBDriver:   "pgx",
+			DBUser:     "root",
+			DBHostname: "localhost",
+			DBPort:     "5432",
+			DBName:     "testdb",
+		},
+	}
+}
+
+func TestValidateAcceptsCompleteConfig(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Errorf("Validate on a complete config: %v", err)
+	}
+}
+
+func TestValidateNamesEachMissingField(t *testing.T) {
+	cases := []struct {
+		name    string
+		mutate  func(c *Config)
+		problem string
+	}{
+		{"worker count", func(c *Config) { c.Runtime.WorkerCount = 0 }, "RUNTIME.WORKER_COUNT"},
+		{"file destination", func(c *Config) { c.Runtime.FileDestination = "" }, "RUNTIME.FILE_DESTINATION"},
+		{"db name", func(c *Config) { c.DB.DBName = "" }, "DATABASE.DB_NAME"},
+		{"db user", func(c *Config) { c.DB.DBUser = "" }, "DATABASE.DB_USER"},
+		{"db hostname", func(c *Config) { c.DB.DBHostname = "" }, "DATABASE.DB_HOSTNAME"},
+		{"db port", func(c *Config) { c.DB.DBPort = "" }, "DATABASE.DB_PORT"},
+		{"unknown driver", func(c *Config) { c.DB.DBDriver = "mssql" }, `DATABASE.DB_DRIVER "mssql"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := validConfig()
+			tc.mutate(cfg)
+			err := cfg.Validate()
+			if err == nil {
+				t.Fatalf("expected a validation error naming %s", tc.problem)
+			}
+			if !strings.Contains(err.Error(), tc.problem) {
+				t.Errorf("error = %q, want it to name %s", err, tc.problem)
+			}
+		})
+	}
+}
+
+func TestValidateCollectsAllProblemsAtOnce(t *testing.T) {
+	cfg := &Config{}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected an empty config to fail validation")
+	}
+	// One pass must report every missing field, not just the first
+	for _, problem := range []string{
+		"RUNTIME.WORKER_COUNT", "RUNTIME.FILE_DESTINATION", "DATABASE.DB_NAME",
+		"DATABASE.DB_USER", "DATABASE.DB_HOSTNAME", "DATABASE.DB_PORT",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("error = %q, want it to also name %s", err, problem)
+		}
+	}
+}
+
+func TestValidateSQLiteSkipsEndpointFields(t *testing.T) {
+	cfg := validConfig()
+	cfg.DB = DatabaseConfig{DBDriver: "sqlite", DBName: "./ingest.db"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate for sqlite without user/hostname/port: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePasswordFile writes a secret file with surrounding whitespace, as a
+// mounted secret with a trailing newline would have.
+func writePasswordFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "db-password")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("writing password file: %v", err)
+	}
+	return path
+}
+
+func TestResolveDBPasswordFileFromConfigWins(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "env-pass")
+	t.Setenv("DB_PASSWORD_FILE", "")
+
+	cfg := validConfig()
+	cfg.DB.DBPassword = "inline-pass"
+	cfg.DB.DBPasswordFile = writePasswordFile(t, "  file-pass\n")
+	if err := cfg.ResolveDBPassword(); err != nil {
+		t.Fatalf("ResolveDBPassword: %v", err)
+	}
+	if cfg.DB.DBPassword != "file-pass" {
+		t.Errorf("DBPassword = %q, want the trimmed file contents to beat env and inline", cfg.DB.DBPassword)
+	}
+}
+
+func TestResolveDBPasswordFileFromEnvWins(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "env-pass")
+	t.Setenv("DB_PASSWORD_FILE", writePasswordFile(t, "file-pass\n"))
+
+	cfg := validConfig()
+	cfg.DB.DBPassword = "inline-pass"
+	if err := cfg.ResolveDBPassword(); err != nil {
+		t.Fatalf("ResolveDBPassword: %v", err)
+	}
+	if cfg.DB.DBPassword != "file-pass" {
+		t.Errorf("DBPassword = %q, want the DB_PASSWORD_FILE env var to beat DB_PASSWORD", cfg.DB.DBPassword)
+	}
+}
+
+func TestResolveDBPasswordEnvBeatsInline(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "env-pass")
+	t.Setenv("DB_PASSWORD_FILE", "")
+
+	cfg := validConfig()
+	cfg.DB.DBPassword = "inline-pass"
+	if err := cfg.ResolveDBPassword(); err != nil {
+		t.Fatalf("ResolveDBPassword: %v", err)
+	}
+	if cfg.DB.DBPassword != "env-pass" {
+		t.Errorf("DBPassword = %q, want the environment to beat the inline config", cfg.DB.DBPassword)
+	}
+}
+
+func TestResolveDBPasswordInlineFallback(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_PASSWORD_FILE", "")
+
+	cfg := validConfig()
+	cfg.DB.DBPassword = "inline-pass"
+	if err := cfg.ResolveDBPassword(); err != nil {
+		t.Fatalf("ResolveDBPassword: %v", err)
+	}
+	if cfg.DB.DBPassword != "inline-pass" {
+		t.Errorf("DBPassword = %q, want the inline value kept when no other source is set", cfg.DB.DBPassword)
+	}
+}
+
+func TestResolveDBPasswordMissingFileFails(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_PASSWORD_FILE", "")
+
+	cfg := validConfig()
+	cfg.DB.DBPasswordFile = filepath.Join(t.TempDir(), "does-not-exist")
+	if err := cfg.ResolveDBPassword(); err == nil {
+		t.Fatal("expected an error for an unreadable DB_PASSWORD_FILE")
+	}
+}
+
+func TestDatabaseConfigStringRedactsPassword(t *testing.T) {
+	cfg := validConfig()
+	cfg.DB.DBPassword = "s3cret"
+
+	rendered := cfg.DB.String()
+	if strings.Contains(rendered, "s3cret") {
+		t.Errorf("String() = %q, must not contain the password", rendered)
+	}
+	if !strings.Contains(rendered, "****") {
+		t.Errorf("String() = %q, want the password shown as ****", rendered)
+	}
+}
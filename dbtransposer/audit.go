@@ -0,0 +1,36 @@
+package dbtransposer
+
+import (
+	"os"
+	"time"
+)
+
+// AuditColumnFunc produces the value for one audit column at insert time.
+type AuditColumnFunc func() interface{}
+
+// DefaultAuditColumns returns the standard audit columns: created_at with the
+// UTC insert time and inserted_by with the invoking OS user. Callers can merge
+// in or replace entries before attaching the map.
+func DefaultAuditColumns() map[string]AuditColumnFunc {
+	return map[string]AuditColumnFunc{
+		"created_at":  func() interface{} { return time.Now().UTC() },
+		"inserted_by": func() interface{} { return os.Getenv("USER") },
+	}
+}
+
+// RegisterAuditColumn attaches an audit column producer to the named column,
+// initializing the audit map on first use.
+func (mp *TransposerFunctions) RegisterAuditColumn(name string, fn AuditColumnFunc) {
+	if mp.AuditColumns == nil {
+		mp.AuditColumns = map[string]AuditColumnFunc{}
+	}
+	mp.AuditColumns[name] = fn
+}
+
+// WithAuditColumns sets the audit columns injected into every record before SQL
+// generation.
+func WithAuditColumns(m map[string]AuditColumnFunc) TransposerOption {
+	return func(mp *TransposerFunctions) {
+		mp.AuditColumns = m
+	}
+}
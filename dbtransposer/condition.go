@@ -0,0 +1,55 @@
+package dbtransposer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// InsertCondition decides whether a record should be inserted at all. Returning
+// false skips the record silently (counted as skipped, not as an error), letting
+// callers drop records without modifying the parse pipeline.
+type InsertCondition func(record map[string]interface{}) bool
+
+// BuildConditionFromJSON parses an inline JSON rule like
+// {"field":"status","operator":"ne","value":"deleted"} into an InsertCondition.
+// Operators match RouteRule semantics: "eq", "ne", "contains", and "prefix". A
+// record missing the field passes the condition, so the rule only filters
+// records that carry a matching value.
+func BuildConditionFromJSON(config string) (InsertCondition, error) {
+	var rule struct {
+		Field    string `json:"field"`
+		Operator string `json:"operator"`
+		Value    string `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(config), &rule); err != nil {
+		return nil, fmt.Errorf("failed to parse skip condition %q: %w", config, err)
+	}
+	if rule.Field == "" {
+		return nil, fmt.Errorf("skip condition: field is required")
+	}
+	switch rule.Operator {
+	case "eq", "ne", "contains", "prefix":
+	default:
+		return nil, fmt.Errorf("skip condition: unknown operator %q (supported: eq, ne, contains, prefix)", rule.Operator)
+	}
+
+	return func(record map[string]interface{}) bool {
+		value, ok := record[rule.Field]
+		if !ok {
+			return true
+		}
+		rendered := fmt.Sprintf("%v", value)
+		switch rule.Operator {
+		case "eq":
+			return rendered == rule.Value
+		case "ne":
+			return rendered != rule.Value
+		case "contains":
+			return strings.Contains(rendered, rule.Value)
+		case "prefix":
+			return strings.HasPrefix(rendered, rule.Value)
+		}
+		return true
+	}, nil
+}
@@ -1,11 +1,19 @@
 package dbtransposer
 
 import (
+	"context"
+	"data-ingestor/fileloader"
+	"data-ingestor/util"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/xuri/excelize/v2"
 	"go.uber.org/zap"
+	"golang.org/x/text/unicode/norm"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // InsertRecordsUsingSchema inserts records into the specified database table.
@@ -22,6 +30,39 @@ import (
 func (mp *TransposerFunctions) InsertRecordsUsingSchema(tx *sql.Tx, tableName string, obj map[string]interface{}) error {
 	// Log the start of the insertion process
 	mp.Logger.Info("Received object in InsertRecords", zap.Any("object", obj))
+
+	// Resolve the record's identifier up front so every failure path can name the
+	// failing record in errors and logs
+	recordID := mp.recordIdentifier(obj)
+
+	// Multi-model records carry the reserved model key; strip it and route the record
+	// to its model's table when one is configured
+	model := ""
+	if rawModel, ok := obj[fileloader.ModelKey]; ok {
+		model = fmt.Sprintf("%v", rawModel)
+		delete(obj, fileloader.ModelKey)
+		if routedTable, ok := mp.ModelTables[model]; ok {
+			tableName = routedTable
+		}
+		mp.Logger.Debug("Routing record by model",
+			zap.String("model", model),
+			zap.String("tableName", tableName))
+	}
+
+	// Rename source keys to their database columns first, so routing rules, the
+	// insert condition, and extraction all see column names
+	if len(mp.KeyColumnMapping) > 0 {
+		mp.applyKeyColumnMapping(model, obj)
+	}
+
+	// Field-value routing overrides both the invocation table and model routing
+	if mp.TableRouter != nil {
+		if routedTable := mp.TableRouter(obj); routedTable != "" {
+			tableName = routedTable
+			mp.Logger.Debug("Routing record by field values",
+				zap.String("tableName", tableName))
+		}
+	}
 	//columns, placeholderCount, err := mp.ExtractSQLDataFromExcel("db-template.xlsx", "Sheet1", "A3:K3", 3)
 
 	//mp.Logger.Info("Extracted SQL Data (From Excel)",
@@ -29,20 +70,81 @@ func (mp *TransposerFunctions) InsertRecordsUsingSchema(tx *sql.Tx, tableName st
 	//	zap.Any("placeholderCount", placeholderCount),
 	//	zap.Any("columns", columns))
 
+	// Skip records the insert condition rejects; they count as skipped, not failed
+	if mp.InsertCondition != nil && !mp.InsertCondition(obj) {
+		mp.Logger.Debug("Skipping record per insert condition",
+			zap.String("record_id", recordID),
+			zap.Any("record", obj))
+		if mp.Counter != nil {
+			mp.Counter.IncrementSkipped(1)
+		}
+		return nil
+	}
+
+	// Tombstone records delete the existing row instead of inserting
+	if mp.SoftDeleteColumn != "" {
+		if value, ok := obj[mp.SoftDeleteColumn]; ok && fmt.Sprintf("%v", value) == mp.SoftDeleteValue {
+			return mp.deleteTombstone(tx, tableName, obj, recordID)
+		}
+	}
+
+	// Let callers mutate the record before SQL generation (e.g. stamping a batch_id)
+	if mp.PreInsertHook != nil {
+		mutated, hookErr := mp.PreInsertHook(context.Background(), tableName, obj)
+		if hookErr != nil {
+			mp.Logger.Error("Pre-insert hook rejected record",
+				zap.String("tableName", tableName),
+				zap.Error(hookErr))
+			return fmt.Errorf("pre-insert hook rejected record: %w", hookErr)
+		}
+		if mutated != nil {
+			obj = mutated
+		}
+	}
+
+	// Resolve lookup-table enrichments (e.g. codes to foreign key IDs) before
+	// SQL generation so the INSERT carries the resolved columns
+	if len(mp.Enrichments) > 0 {
+		if err := mp.applyEnrichments(obj); err != nil {
+			mp.Logger.Error("Failed to enrich record",
+				zap.String("record_id", recordID),
+				zap.Any("object", obj),
+				zap.Error(err))
+			mp.recordDeadLetter(obj, err)
+			return fmt.Errorf("failed to enrich record (%s): %w", recordID, err)
+		}
+	}
+
 	// Extract SQL columns and rows from the object using ExtractSQLData
 	columns, rows, err := mp.ExtractSQLDataUsingSchema(obj)
 	if err != nil {
 		// Log and return an error if data extraction fails
 		mp.Logger.Error("Failed to extract SQL data",
+			zap.String("record_id", recordID),
 			zap.Any("object", obj), // Log the full object
 			zap.Error(err))
-		return fmt.Errorf("failed to extract SQL data: %w", err)
+		mp.recordDeadLetter(obj, err)
+		return fmt.Errorf("failed to extract SQL data (%s): %w", recordID, err)
+	}
+
+	// Dry-run mode: render the statement into the SQL script instead of executing.
+	// The columns and rows are exactly what the live path would bind, so the
+	// script's batching matches a real run.
+	if mp.ScriptWriter != nil {
+		if err := mp.ScriptWriter.WriteInsert(tableName, columns, rows, mp.SkipDuplicates); err != nil {
+			mp.Logger.Error("Failed to write dry-run SQL statement", zap.Error(err))
+			return err
+		}
+		if model != "" {
+			mp.recordModelInsert(model, len(rows))
+		}
+		return nil
 	}
 
 	// Build the base INSERT query with the table name and columns
 	query := fmt.Sprintf(
 		`INSERT INTO %s (%s) VALUES `,
-		tableName,
+		quoteTableName(tableName),
 		strings.Join(columns, ", "),
 	)
 
@@ -59,8 +161,8 @@ func (mp *TransposerFunctions) InsertRecordsUsingSchema(tx *sql.Tx, tableName st
 		// Create a slice for placeholders for the current row
 		rowPlaceholders := []string{}
 		for range row {
-			// Generate placeholder strings (e.g., $1, $2, ...)
-			rowPlaceholders = append(rowPlaceholders, fmt.Sprintf("$%d", placeholderIndex))
+			// Generate placeholder strings in the configured driver's style ($n or ?)
+			rowPlaceholders = append(rowPlaceholders, mp.formatPlaceholder(placeholderIndex))
 			placeholderIndex++
 		}
 
@@ -79,19 +181,54 @@ func (mp *TransposerFunctions) InsertRecordsUsingSchema(tx *sql.Tx, tableName st
 	// Combine the query with all generated placeholders
 	query += strings.Join(allPlaceholders, ", ")
 
+	// Skip duplicate deliveries silently when configured rather than failing the batch
+	if mp.SkipDuplicates {
+		query += " ON CONFLICT DO NOTHING"
+	}
+
 	// Log the final SQL query and values before execution
 	mp.Logger.Info("Final SQL query being executed", zap.String("query", query))
 	mp.Logger.Info("All Values to Execute in SQL", zap.Any("All Values", allValues))
 
 	// Execute the SQL query with the collected values
-	_, err = tx.Exec(query, allValues...)
+	result, err := tx.Exec(query, allValues...)
+
+	// Notify side-effect hooks of the outcome, successful or not
+	if mp.PostInsertHook != nil {
+		mp.PostInsertHook(context.Background(), tableName, obj, err)
+	}
+
 	if err != nil {
-		// Log and return an error if query execution fails
+		// Log and return an error if query execution fails, naming the failing
+		// record so it can be found in the source file
 		mp.Logger.Error("Failed to execute SQL query",
 			zap.String("query", query),
+			zap.String("record_id", recordID),
 			zap.Any("record", obj), // Log the full object
 			zap.Error(err))
-		return fmt.Errorf("failed to insert records: %w", err)
+		mp.recordDeadLetter(obj, err)
+		return fmt.Errorf("failed to insert record (%s): %w", recordID, err)
+	}
+
+	// Count the rows the database actually wrote, which can differ from the record
+	// count when flattening exploded the record into multiple rows or ON CONFLICT
+	// DO NOTHING skipped duplicates
+	if affected, raErr := result.RowsAffected(); raErr == nil {
+		if mp.Counter != nil {
+			mp.Counter.IncrementRowsInserted(int(affected))
+		}
+		if mp.SkipDuplicates {
+			if skipped := int64(len(rows)) - affected; skipped > 0 {
+				mp.Logger.Info("Skipped duplicate rows",
+					zap.Int64("skipped", skipped),
+					zap.Int64("inserted", affected))
+				if mp.Counter != nil {
+					mp.Counter.IncrementSkipped(int(skipped))
+				}
+			}
+		}
+	} else {
+		mp.Logger.Warn("Could not determine rows affected", zap.Error(raErr))
 	}
 
 	// Log successful execution of the SQL query
@@ -99,9 +236,245 @@ func (mp *TransposerFunctions) InsertRecordsUsingSchema(tx *sql.Tx, tableName st
 		zap.String("query", query),
 		zap.Any("record", obj)) // Log the full object
 
+	// Track per-model insert counts for the final summary
+	if model != "" {
+		mp.recordModelInsert(model, len(rows))
+	}
+
 	return nil
 }
 
+// InsertRecordsBatch is the BatchMapFunc counterpart of InsertRecordsUsingSchema:
+// it extracts every record in the batch through the same transform/mask/coerce/
+// validate pipeline and writes them with a single multi-row INSERT. Records that
+// fail extraction go to the dead-letter file and fail the whole batch, so use the
+// per-record path when one bad record must not reject its neighbors. Multi-model
+// routing uses the first record's model for the entire batch; batches are expected
+// to be homogeneous.
+//
+// Parameters:
+// - tx: The database transaction used for executing the SQL query.
+// - tableName: The name of the table to insert the records into.
+// - batch: The records to insert in one statement.
+//
+// Returns:
+// - An error if extraction or the SQL query execution fails for any record.
+func (mp *TransposerFunctions) InsertRecordsBatch(tx *sql.Tx, tableName string, batch []map[string]interface{}) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	mp.Logger.Info("Received batch in InsertRecordsBatch", zap.Int("batchSize", len(batch)))
+
+	// Route the whole batch by the first record's model; the reserved key is
+	// stripped from every record either way
+	model := ""
+	for i, obj := range batch {
+		if rawModel, ok := obj[fileloader.ModelKey]; ok {
+			if i == 0 {
+				model = fmt.Sprintf("%v", rawModel)
+				if routedTable, ok := mp.ModelTables[model]; ok {
+					tableName = routedTable
+				}
+			}
+			delete(obj, fileloader.ModelKey)
+		}
+	}
+
+	// Rename source keys to their database columns before extraction
+	if len(mp.KeyColumnMapping) > 0 {
+		for _, obj := range batch {
+			mp.applyKeyColumnMapping(model, obj)
+		}
+	}
+
+	// Extract each record, building the union of columns in first-seen order so
+	// records with differing key sets still share one statement. Each record runs
+	// through the same pre-insert pipeline as the per-record path — condition
+	// skip, tombstone DELETE, hook, enrichment — so switching to batched mode
+	// does not change ingestion semantics.
+	var columns []string
+	columnIndex := map[string]int{}
+	recordValues := make([]map[string]interface{}, 0, len(batch))
+	inserted := make([]map[string]interface{}, 0, len(batch))
+	for i, obj := range batch {
+		// Skip records the insert condition rejects; they count as skipped, not failed
+		if mp.InsertCondition != nil && !mp.InsertCondition(obj) {
+			mp.Logger.Debug("Skipping batch record per insert condition",
+				zap.String("record_id", mp.recordIdentifier(obj)),
+				zap.Any("record", obj))
+			if mp.Counter != nil {
+				mp.Counter.IncrementSkipped(1)
+			}
+			continue
+		}
+
+		// Tombstone records delete the existing row instead of joining the INSERT
+		if mp.SoftDeleteColumn != "" {
+			if value, ok := obj[mp.SoftDeleteColumn]; ok && fmt.Sprintf("%v", value) == mp.SoftDeleteValue {
+				if err := mp.deleteTombstone(tx, tableName, obj, mp.recordIdentifier(obj)); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if mp.PreInsertHook != nil {
+			mutated, hookErr := mp.PreInsertHook(context.Background(), tableName, obj)
+			if hookErr != nil {
+				mp.Logger.Error("Pre-insert hook rejected record in batch",
+					zap.String("tableName", tableName),
+					zap.Error(hookErr))
+				return fmt.Errorf("pre-insert hook rejected record: %w", hookErr)
+			}
+			if mutated != nil {
+				obj = mutated
+				batch[i] = mutated
+			}
+		}
+
+		if len(mp.Enrichments) > 0 {
+			if err := mp.applyEnrichments(obj); err != nil {
+				mp.Logger.Error("Failed to enrich record",
+					zap.Any("object", obj),
+					zap.Error(err))
+				mp.recordDeadLetter(obj, err)
+				return fmt.Errorf("failed to enrich record: %w", err)
+			}
+		}
+
+		recordColumns, recordRows, err := mp.ExtractSQLDataUsingSchema(obj)
+		if err != nil {
+			recordID := mp.recordIdentifier(obj)
+			mp.Logger.Error("Failed to extract SQL data for batch record",
+				zap.String("record_id", recordID),
+				zap.Any("object", obj),
+				zap.Error(err))
+			mp.recordDeadLetter(obj, err)
+			return fmt.Errorf("failed to extract SQL data (%s): %w", recordID, err)
+		}
+		for _, row := range recordRows {
+			values := map[string]interface{}{}
+			for idx, col := range recordColumns {
+				if _, ok := columnIndex[col]; !ok {
+					columnIndex[col] = len(columns)
+					columns = append(columns, col)
+				}
+				values[col] = row[idx]
+			}
+			recordValues = append(recordValues, values)
+		}
+		inserted = append(inserted, obj)
+	}
+
+	// Every record may have been skipped or tombstoned; nothing to insert
+	if len(recordValues) == 0 {
+		return nil
+	}
+
+	// Align every row to the unified column list, filling columns a record lacks
+	// with nil
+	rows := make([][]interface{}, 0, len(recordValues))
+	for _, values := range recordValues {
+		row := make([]interface{}, len(columns))
+		for col, idx := range columnIndex {
+			row[idx] = values[col]
+		}
+		rows = append(rows, row)
+	}
+
+	// Dry-run mode: render the statement into the SQL script instead of executing
+	if mp.ScriptWriter != nil {
+		if err := mp.ScriptWriter.WriteInsert(tableName, columns, rows, mp.SkipDuplicates); err != nil {
+			mp.Logger.Error("Failed to write dry-run SQL statement", zap.Error(err))
+			return err
+		}
+		if model != "" {
+			mp.recordModelInsert(model, len(rows))
+		}
+		return nil
+	}
+
+	// Build one INSERT covering every row in the batch
+	query := fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES `,
+		quoteTableName(tableName),
+		strings.Join(columns, ", "),
+	)
+	var allPlaceholders []string
+	var allValues []interface{}
+	placeholderIndex := 1
+	for _, row := range rows {
+		rowPlaceholders := []string{}
+		for range row {
+			rowPlaceholders = append(rowPlaceholders, mp.formatPlaceholder(placeholderIndex))
+			placeholderIndex++
+		}
+		allPlaceholders = append(allPlaceholders, fmt.Sprintf("(%s)", strings.Join(rowPlaceholders, ", ")))
+		allValues = append(allValues, row...)
+	}
+	query += strings.Join(allPlaceholders, ", ")
+
+	// Skip duplicate deliveries silently when configured rather than failing the batch
+	if mp.SkipDuplicates {
+		query += " ON CONFLICT DO NOTHING"
+	}
+
+	mp.Logger.Debug("Final batched SQL query being executed",
+		zap.String("query", query),
+		zap.Int("rowCount", len(rows)))
+
+	result, err := tx.Exec(query, allValues...)
+
+	// Notify side-effect hooks of the outcome per inserted record, successful or
+	// not; skipped and tombstoned records never reach the hook
+	if mp.PostInsertHook != nil {
+		for _, obj := range inserted {
+			mp.PostInsertHook(context.Background(), tableName, obj, err)
+		}
+	}
+
+	if err != nil {
+		mp.Logger.Error("Failed to execute batched SQL query",
+			zap.String("query", query),
+			zap.Int("batchSize", len(inserted)),
+			zap.Error(err))
+		for _, obj := range inserted {
+			mp.recordDeadLetter(obj, err)
+		}
+		return fmt.Errorf("failed to insert records: %w", err)
+	}
+
+	// Count the rows the database actually wrote, which can differ from the row
+	// count when ON CONFLICT DO NOTHING skipped duplicates
+	if affected, raErr := result.RowsAffected(); raErr == nil {
+		if mp.Counter != nil {
+			mp.Counter.IncrementRowsInserted(int(affected))
+		}
+		if mp.SkipDuplicates {
+			if skipped := int64(len(rows)) - affected; skipped > 0 {
+				mp.Logger.Info("Skipped duplicate rows",
+					zap.Int64("skipped", skipped),
+					zap.Int64("inserted", affected))
+				if mp.Counter != nil {
+					mp.Counter.IncrementSkipped(int(skipped))
+				}
+			}
+		}
+	} else {
+		mp.Logger.Warn("Could not determine rows affected", zap.Error(raErr))
+	}
+
+	mp.Logger.Info("Successfully executed batched SQL query",
+		zap.String("tableName", tableName),
+		zap.Int("rowCount", len(rows)))
+
+	// Track per-model insert counts for the final summary
+	if model != "" {
+		mp.recordModelInsert(model, len(rows))
+	}
+
+	return nil
+}
 
 // ExtractSQLDataUsingSchema extracts SQL column names and rows from a record based on a map.
 // This function processes:
@@ -121,12 +494,111 @@ func (mp *TransposerFunctions) ExtractSQLDataUsingSchema(record map[string]inter
 	columns := []string{}
 	rows := [][]interface{}{}
 
-	// Flatten the record into columns and values
+	// Inject the audit columns before SQL generation, overriding any incoming
+	// values so the metadata always reflects this insert
+	for name, fn := range mp.AuditColumns {
+		record[name] = fn()
+	}
+
+	// Emit columns in a fixed, declared order so every record in a run produces
+	// the same column list: the template column list when one is set, otherwise
+	// the record's keys sorted. Ranging over the map directly would let two
+	// records in one multi-row INSERT land values in each other's columns.
+	ordered := mp.TemplateColumns
+	if len(ordered) == 0 {
+		ordered = make([]string, 0, len(record))
+		for key := range record {
+			ordered = append(ordered, key)
+		}
+		sort.Strings(ordered)
+	} else {
+		// Record keys outside the declared schema are ignored, with a warning so
+		// silently dropped data is visible
+		declared := make(map[string]bool, len(ordered))
+		for _, col := range ordered {
+			declared[col] = true
+		}
+		for key := range record {
+			if !declared[key] {
+				mp.Logger.Warn("Ignoring record key outside the schema columns",
+					zap.String("key", key))
+			}
+		}
+	}
+
+	// Flatten the record into columns and values, coercing typed columns as we go
 	row := []interface{}{}
-	for key, value := range record {
+	var coercionFailures []FieldCoercionError
+	var violations []FieldError
+	for _, key := range ordered {
+		value, present := record[key]
+		if !present {
+			// Fill schema columns missing from the record so the INSERT covers the
+			// full column list: configured defaults first, then nil for nullable
+			// columns
+			if defaultValue, ok := mp.DefaultValues[key]; ok {
+				violations = append(violations, mp.validateColumn(key, defaultValue)...)
+				columns = append(columns, quoteColumn(key))
+				row = append(row, defaultValue)
+				continue
+			}
+			if mp.isNotNullColumn(key) {
+				mp.Logger.Error("Record missing NOT NULL column with no default",
+					zap.String("column", key),
+					zap.Any("record", record))
+				return nil, nil, fmt.Errorf("%w: %s", ErrMissingRequiredColumn, key)
+			}
+			violations = append(violations, mp.validateColumn(key, nil)...)
+			columns = append(columns, quoteColumn(key))
+			row = append(row, nil)
+			continue
+		}
+
+		// Run the column's registered transformer first so normalization and
+		// coercion see the cleaned value
+		if transformer, ok := mp.ColumnTransformers[key]; ok {
+			transformed, err := transformer(value)
+			if err != nil {
+				coercionFailures = append(coercionFailures, FieldCoercionError{
+					Column: key,
+					Value:  value,
+					Err:    fmt.Errorf("transformer failed: %w", err),
+				})
+				continue
+			}
+			value = transformed
+		}
+		// Mask PII columns after transformation but before anything reaches SQL
+		if mask, ok := mp.MaskedColumns[key]; ok {
+			value = mask(value)
+		}
+		value = mp.normalizeValue(key, value)
+		coerced, err := mp.coerceValue(key, value)
+		if err != nil {
+			coercionFailures = append(coercionFailures, FieldCoercionError{
+				Column: key,
+				Value:  value,
+				Err:    err,
+			})
+			continue
+		}
+
+		// Run the column's validation rules against the cleaned value
+		violations = append(violations, mp.validateColumn(key, coerced)...)
+
 		// Append the column name and value
-		columns = append(columns, fmt.Sprintf(`"%s"`, key))
-		row = append(row, value)
+		columns = append(columns, quoteColumn(key))
+		row = append(row, coerced)
+	}
+
+	// Report every field-level coercion failure so the caller can decide to skip or reject
+	if len(coercionFailures) > 0 {
+		return nil, nil, &CoercionError{Fields: coercionFailures}
+	}
+
+	// Skip the record when any validation rule failed, reporting every violation
+	if len(violations) > 0 {
+		return nil, nil, &RecordValidationError{Violations: violations}
 	}
 
 	// Add the row to rows
@@ -141,6 +613,435 @@ func (mp *TransposerFunctions) ExtractSQLDataUsingSchema(record map[string]inter
 	return columns, rows, nil
 }
 
+// deleteTombstone executes the DELETE a soft-delete tombstone record stands for,
+// matching on the configured primary key column. Successful deletes count on
+// Counter as deleted (the worker counts the record as succeeded); a tombstone
+// without a usable primary key value is an error, since silently dropping it
+// would leave the row it was meant to remove.
+func (mp *TransposerFunctions) deleteTombstone(tx *sql.Tx, tableName string, obj map[string]interface{}, recordID string) error {
+	if mp.PKColumn == "" {
+		return fmt.Errorf("soft-delete record (%s) received but no primary key column is configured", recordID)
+	}
+	pkValue, ok := obj[mp.PKColumn]
+	if !ok || pkValue == nil {
+		mp.Logger.Error("Tombstone record is missing its primary key value",
+			zap.String("pkColumn", mp.PKColumn),
+			zap.String("record_id", recordID),
+			zap.Any("record", obj))
+		mp.recordDeadLetter(obj, fmt.Errorf("%w: %s", ErrMissingRequiredColumn, mp.PKColumn))
+		return fmt.Errorf("tombstone record (%s) is missing primary key column %q", recordID, mp.PKColumn)
+	}
+
+	// Dry-run mode covers INSERTs only; report the delete without executing it
+	if mp.ScriptWriter != nil {
+		mp.Logger.Info("Dry-run: skipping tombstone DELETE",
+			zap.String("tableName", tableName),
+			zap.String("record_id", recordID))
+		return nil
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s = %s`, quoteTableName(tableName), quoteColumn(mp.PKColumn), mp.formatPlaceholder(1))
+	result, err := tx.Exec(query, pkValue)
+	if err != nil {
+		mp.Logger.Error("Failed to execute tombstone DELETE",
+			zap.String("query", query),
+			zap.String("record_id", recordID),
+			zap.Error(err))
+		mp.recordDeadLetter(obj, err)
+		return fmt.Errorf("failed to delete record (%s): %w", recordID, err)
+	}
+
+	affected, raErr := result.RowsAffected()
+	if raErr != nil {
+		mp.Logger.Warn("Could not determine rows affected by tombstone DELETE", zap.Error(raErr))
+	}
+	if mp.Counter != nil {
+		mp.Counter.IncrementDeleted(1)
+	}
+	mp.Logger.Info("Deleted row for tombstone record",
+		zap.String("tableName", tableName),
+		zap.String("record_id", recordID),
+		zap.Int64("rowsAffected", affected))
+	return nil
+}
+
+// recordDeadLetter writes a rejected record to the dead-letter file when one is
+// configured, classifying the rejection by the error's type. The record index is
+// unknown at this stage and recorded as -1.
+func (mp *TransposerFunctions) recordDeadLetter(record map[string]interface{}, err error) {
+	reason := util.DeadLetterReasonInsert
+	var validationErr *RecordValidationError
+	var coercionErr *CoercionError
+	if errors.As(err, &validationErr) {
+		reason = util.DeadLetterReasonValidation
+	} else if errors.As(err, &coercionErr) {
+		reason = util.DeadLetterReasonCoercion
+	}
+	mp.DeadLetter.Record(util.DeadLetterEntry{
+		RecordIndex: -1,
+		Reason:      reason,
+		Error:       err.Error(),
+		Record:      record,
+	})
+}
+
+// validateColumn runs every rule registered for the column against the value,
+// logging each failure at DEBUG and returning the collected violations. Records
+// with violations are skipped through the worker's error path, which increments
+// the error counter.
+func (mp *TransposerFunctions) validateColumn(column string, value interface{}) []FieldError {
+	var violations []FieldError
+	for _, rule := range mp.ValidationRules[column] {
+		if err := rule.Validate(column, value); err != nil {
+			mp.Logger.Debug("Validation rule failed",
+				zap.String("column", column),
+				zap.Any("value", value),
+				zap.Error(err))
+			violations = append(violations, FieldError{Column: column, Value: value, Err: err})
+		}
+	}
+	return violations
+}
+
+// isNotNullColumn reports whether the column is configured as NOT NULL.
+func (mp *TransposerFunctions) isNotNullColumn(column string) bool {
+	for _, col := range mp.NotNullColumns {
+		if col == column {
+			return true
+		}
+	}
+	return false
+}
+
+
+// FieldCoercionError describes a single column value that could not be coerced to its
+// configured type.
+type FieldCoercionError struct {
+	Column string
+	Value  interface{}
+	Err    error
+}
+
+// CoercionError aggregates every field-level coercion failure for one record so the
+// caller can decide whether to skip or reject it.
+type CoercionError struct {
+	Fields []FieldCoercionError
+}
+
+func (e *CoercionError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, fmt.Sprintf("%s (%v): %v", f.Column, f.Value, f.Err))
+	}
+	return fmt.Sprintf("failed to coerce %d field(s): %s", len(e.Fields), strings.Join(parts, "; "))
+}
+
+// coerceValue converts a value to the Go type registered for its column in TypeMapping.
+// Columns without a registered type (and nil values) pass through unchanged.
+func (mp *TransposerFunctions) coerceValue(column string, value interface{}) (interface{}, error) {
+	targetType, ok := mp.TypeMapping[column]
+	if !ok || value == nil {
+		return value, nil
+	}
+
+	switch targetType {
+	case "string":
+		return fmt.Sprintf("%v", value), nil
+	case "int64":
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			return int64(v), nil
+		case string:
+			parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("not a valid int64: %w", err)
+			}
+			return parsed, nil
+		}
+		return nil, fmt.Errorf("cannot coerce %T to int64", value)
+	case "float64":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("not a valid float64: %w", err)
+			}
+			return parsed, nil
+		}
+		return nil, fmt.Errorf("cannot coerce %T to float64", value)
+	case "bool":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("not a valid bool: %w", err)
+			}
+			return parsed, nil
+		}
+		return nil, fmt.Errorf("cannot coerce %T to bool", value)
+	case "time.RFC3339":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot coerce %T to time.RFC3339", value)
+		}
+		parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("not a valid RFC3339 timestamp: %w", err)
+		}
+		return parsed, nil
+	default:
+		return nil, fmt.Errorf("unknown target type %q", targetType)
+	}
+}
+
+// parseRange resolves a range spec like "A3:K3" to its 1-based start/end column and
+// row indices. A single-cell spec ("A3") yields equal starts and ends; reversed specs
+// are normalized.
+func parseRange(rangeSpec string) (startCol, endCol, startRow, endRow int, err error) {
+	parts := strings.Split(rangeSpec, ":")
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	if len(parts) != 2 {
+		return 0, 0, 0, 0, fmt.Errorf("invalid range spec: %s", rangeSpec)
+	}
+
+	startCol, startRow, err = excelize.CellNameToCoordinates(parts[0])
+	if err != nil {
+		return 0, 0, 0, 0, fmt.Errorf("invalid range start %q: %w", parts[0], err)
+	}
+	endCol, endRow, err = excelize.CellNameToCoordinates(parts[1])
+	if err != nil {
+		return 0, 0, 0, 0, fmt.Errorf("invalid range end %q: %w", parts[1], err)
+	}
+
+	if endCol < startCol {
+		startCol, endCol = endCol, startCol
+	}
+	if endRow < startRow {
+		startRow, endRow = endRow, startRow
+	}
+	return startCol, endCol, startRow, endRow, nil
+}
+
+// parseNormalForm maps a Unicode normalization form name to its x/text normalizer.
+func parseNormalForm(name string) (norm.Form, bool) {
+	switch strings.ToUpper(name) {
+	case "NFC":
+		return norm.NFC, true
+	case "NFD":
+		return norm.NFD, true
+	case "NFKC":
+		return norm.NFKC, true
+	case "NFKD":
+		return norm.NFKD, true
+	}
+	return norm.NFC, false
+}
+
+// normalizeValue applies the configured Unicode normalization to string values so text
+// is stored consistently regardless of the source's normalization form. Non-string values
+// and columns outside NormalizeColumns (when set) pass through unchanged.
+func (mp *TransposerFunctions) normalizeValue(column string, value interface{}) interface{} {
+	if mp.UnicodeNormalization == "" {
+		return value
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+
+	// Restrict to the configured columns when a subset is given
+	if len(mp.NormalizeColumns) > 0 {
+		matched := false
+		for _, col := range mp.NormalizeColumns {
+			if col == column {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return value
+		}
+	}
+
+	form, ok := parseNormalForm(mp.UnicodeNormalization)
+	if !ok {
+		mp.Logger.Warn("Unknown Unicode normalization form, skipping",
+			zap.String("form", mp.UnicodeNormalization))
+		return value
+	}
+	return form.String(s)
+}
+
+// CheckTableExists verifies that the target table exists before any streaming begins,
+// converting an opaque mid-run Postgres error into an immediate, actionable startup error.
+//
+// Parameters:
+//   - db: The database connection to check against.
+//   - tableName: The name of the table to verify.
+//
+// Returns:
+//   - An error naming the missing table, or nil when the table exists.
+func (mp *TransposerFunctions) CheckTableExists(db *sql.DB, tableName string) error {
+	// A zero-row select is a cheap way to validate the table without reading data.
+	// Exec rather than Query so no *sql.Rows is left open holding a pool
+	// connection, and the name is quoted rather than interpolated raw.
+	query := fmt.Sprintf(`SELECT * FROM %s LIMIT 0`, quoteTableName(tableName))
+	if _, err := db.Exec(query); err != nil {
+		mp.Logger.Error("Target table does not exist or is not accessible",
+			zap.String("tableName", tableName),
+			zap.Error(err))
+		return fmt.Errorf("table %q does not exist or is not accessible: %w", tableName, err)
+	}
+
+	mp.Logger.Info("Verified target table exists", zap.String("tableName", tableName))
+	return nil
+}
+
+// TruncateBeforeInsert empties the target table in a standalone transaction ahead of a
+// full-refresh load. Callers are expected to have passed the two-flag safety check
+// (-truncate plus -confirm-truncate=<table>) before invoking this.
+//
+// Parameters:
+//   - db: The database connection to execute the TRUNCATE on.
+//   - tableName: The name of the table to truncate.
+//
+// Returns:
+//   - An error if the row count query, TRUNCATE, or commit fails.
+func (mp *TransposerFunctions) TruncateBeforeInsert(db *sql.DB, tableName string) error {
+	// Surface how much data is about to be dropped before doing it
+	var rowCount int64
+	if err := db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s`, quoteTableName(tableName))).Scan(&rowCount); err != nil {
+		mp.Logger.Error("Failed to count rows before truncate",
+			zap.String("tableName", tableName),
+			zap.Error(err))
+		return fmt.Errorf("failed to count rows in %q before truncate: %w", tableName, err)
+	}
+
+	mp.Logger.Warn("Truncating table before insert",
+		zap.String("tableName", tableName),
+		zap.Int64("existingRows", rowCount))
+
+	// Run the TRUNCATE in its own transaction, separate from the ingest workers
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin truncate transaction: %w", err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`TRUNCATE TABLE %s RESTART IDENTITY`, quoteTableName(tableName))); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			mp.Logger.Error("Failed to rollback truncate transaction", zap.Error(rbErr))
+		}
+		mp.Logger.Error("Failed to truncate table",
+			zap.String("tableName", tableName),
+			zap.Error(err))
+		return fmt.Errorf("failed to truncate table %q: %w", tableName, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit truncate of %q: %w", tableName, err)
+	}
+
+	mp.Logger.Info("Table truncated",
+		zap.String("tableName", tableName),
+		zap.Int64("droppedRows", rowCount))
+	return nil
+}
+
+// CreateTableFromColumns creates the target table with one TEXT column per template column.
+// It is intended as an opt-in convenience (-create-table) when the table is missing; production
+// deployments are expected to manage their own DDL.
+//
+// Parameters:
+//   - db: The database connection to execute the DDL on.
+//   - tableName: The name of the table to create.
+//   - columns: The template column names to create as TEXT columns.
+//
+// Returns:
+//   - An error if the CREATE TABLE statement fails.
+func (mp *TransposerFunctions) CreateTableFromColumns(db *sql.DB, tableName string, columns []string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("cannot create table %q: no template columns provided", tableName)
+	}
+
+	// Build the column definitions from the template columns
+	defs := []string{}
+	for _, col := range columns {
+		defs = append(defs, quoteColumn(col)+" TEXT")
+	}
+
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (%s)`, quoteTableName(tableName), strings.Join(defs, ", "))
+	mp.Logger.Info("Creating target table from template columns",
+		zap.String("tableName", tableName),
+		zap.String("query", query))
+
+	if _, err := db.Exec(query); err != nil {
+		mp.Logger.Error("Failed to create target table",
+			zap.String("tableName", tableName),
+			zap.Error(err))
+		return fmt.Errorf("failed to create table %q: %w", tableName, err)
+	}
+
+	mp.Logger.Info("Created target table", zap.String("tableName", tableName))
+	return nil
+}
+
+// LoadSchemaFromDB loads the target table's column list from PostgreSQL's
+// information_schema, as an alternative to the Excel template for deployments where a
+// spreadsheet is impractical. Columns are returned in ordinal position order so the
+// result is deterministic.
+//
+// Parameters:
+//   - ctx: Context for the schema query.
+//   - tableName: The name of the table whose columns to load.
+//
+// Returns:
+//   - The ordered column names, or an error if the query fails or the table has none.
+func (mp *TransposerFunctions) LoadSchemaFromDB(ctx context.Context, tableName string) ([]string, error) {
+	if mp.DB == nil {
+		return nil, fmt.Errorf("no database connection available for schema loading")
+	}
+
+	rows, err := mp.DB.QueryContext(ctx,
+		`SELECT column_name FROM information_schema.columns
+		 WHERE table_name = $1 AND table_schema = current_schema()
+		 ORDER BY ordinal_position`, tableName)
+	if err != nil {
+		mp.Logger.Error("Failed to query information_schema for columns",
+			zap.String("tableName", tableName),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to load schema for table %q: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	columns := []string{}
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan column name: %w", err)
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema rows: %w", err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %q has no columns in information_schema (does it exist?)", tableName)
+	}
+
+	mp.Logger.Info("Loaded schema from database",
+		zap.String("tableName", tableName),
+		zap.Strings("columns", columns))
+	return columns, nil
+}
 
 // ExtractSQLDataFromExcel processes an Excel file to determine SQL column names and placeholders based on a range and line.
 // This function handles:
@@ -158,6 +1059,10 @@ func (mp *TransposerFunctions) ExtractSQLDataUsingSchema(record map[string]inter
 //   - placeholderCount: The number of placeholders based on the line.
 //   - error: An error, if any issues occur during processing.
 func (mp *TransposerFunctions) ExtractSQLDataFromExcel(filePath, sheetName, rangeSpec string, line int) ([]string, int, error) {
+	if line < 1 {
+		return nil, 0, fmt.Errorf("header line must be >= 1, got %d", line)
+	}
+
 	// Open the Excel file
 	file, err := excelize.OpenFile(filePath)
 	if err != nil {
@@ -166,25 +1071,78 @@ func (mp *TransposerFunctions) ExtractSQLDataFromExcel(filePath, sheetName, rang
 	}
 	defer file.Close()
 
-	// Read the specified range to get column names
-	rows, err := file.GetRows(sheetName)
+	// Fail with a clear message when the sheet is missing rather than relying on the
+	// row iterator's error
+	if idx, err := file.GetSheetIndex(sheetName); err != nil || idx < 0 {
+		mp.Logger.Error("Sheet not found in Excel file", zap.String("filePath", filePath), zap.String("sheetName", sheetName))
+		return nil, 0, fmt.Errorf("sheet %q does not exist in %s (sheets: %v)", sheetName, filePath, file.GetSheetList())
+	}
+
+	// Parse the range so only cells inside the intended rectangle are extracted
+	startCol, endCol, startRow, endRow, err := parseRange(rangeSpec)
+	if err != nil {
+		mp.Logger.Error("Failed to parse range spec", zap.String("rangeSpec", rangeSpec), zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to parse range spec %q: %w", rangeSpec, err)
+	}
+
+	// The rows named by the range are authoritative. A single-row range that
+	// disagrees with the line argument used to be silently overridden by the
+	// line, reading the wrong row; fail loudly instead so the misconfiguration
+	// is visible. Multi-row ranges read every row of the rectangle.
+	if startRow == endRow && startRow != line {
+		return nil, 0, fmt.Errorf("range %q names row %d but the header line is %d; align HEADER_RANGE and HEADER_LINE", rangeSpec, startRow, line)
+	}
+
+	// Stream rows instead of loading the whole sheet when only one row is needed
+	rowIter, err := file.Rows(sheetName)
 	if err != nil {
 		mp.Logger.Error("Failed to read rows from sheet", zap.String("sheetName", sheetName), zap.Error(err))
 		return nil, 0, fmt.Errorf("failed to read rows from sheet: %w", err)
 	}
+	defer rowIter.Close()
 
 	columns := []string{}
 	placeholderCount := 0
 
-	// Process the specified line to determine placeholders
-	if line <= len(rows) {
-		for _, cell := range rows[line-1] { // Adjusting for 0-based index
+	// Advance the iterator to the requested row(s)
+	currentLine := 0
+	found := false
+	for rowIter.Next() {
+		currentLine++
+		if currentLine < startRow {
+			continue
+		}
+		if currentLine > endRow {
+			break
+		}
+		found = true
+
+		cells, err := rowIter.Columns()
+		if err != nil {
+			mp.Logger.Error("Failed to read row cells", zap.String("sheetName", sheetName), zap.Int("line", currentLine), zap.Error(err))
+			return nil, 0, fmt.Errorf("failed to read row cells: %w", err)
+		}
+
+		// Restrict extraction to the columns covered by the range spec; a row shorter
+		// than the range simply contributes fewer cells
+		for idx, cell := range cells {
+			col := idx + 1 // Cell slices are 0-based; ranges are 1-based
+			if col < startCol || col > endCol {
+				continue
+			}
 			if cell != "" {
 				columns = append(columns, cell)
 				placeholderCount++
 			}
 		}
 	}
+	if !found {
+		mp.Logger.Error("Header range beyond sheet bounds",
+			zap.String("sheetName", sheetName),
+			zap.Int("startRow", startRow),
+			zap.Int("rowCount", currentLine))
+		return nil, 0, fmt.Errorf("header row %d is beyond the last row (%d) of sheet %q", startRow, currentLine, sheetName)
+	}
 
 	// Log the placeholder count
 	mp.Logger.Info("Determined Columns and Count from Excel",
@@ -199,3 +1157,50 @@ func (mp *TransposerFunctions) ExtractSQLDataFromExcel(filePath, sheetName, rang
 	return columns, placeholderCount, nil
 }
 
+// ExtractSQLDataFromTemplate reads the column template described by the EXCEL_TEMPLATE
+// config section, falling back to the historical db-template.xlsx / Sheet1 / A3:K3 /
+// line 3 layout for any unset field.
+//
+// The workbook is parsed once per TransposerFunctions instance and the result is
+// cached; every subsequent call (including the per-record insert path) returns the
+// cached columns instead of re-opening the file.
+//
+// Returns:
+//   - columns: A list of column names.
+//   - placeholderCount: The number of placeholders based on the header line.
+//   - error: An error, if any issues occur during processing.
+func (mp *TransposerFunctions) ExtractSQLDataFromTemplate() ([]string, int, error) {
+	mp.templateOnce.Do(func() {
+		template := mp.CONFIG.ExcelTemplate
+		if template.FilePath == "" {
+			template.FilePath = "db-template.xlsx"
+		}
+		if template.SheetName == "" {
+			template.SheetName = "Sheet1"
+		}
+		if template.HeaderRange == "" {
+			template.HeaderRange = "A3:K3"
+		}
+		if template.HeaderLine == 0 {
+			// Derive the line from the range so configuring HEADER_RANGE alone
+			// works; an unparseable range keeps the historical line 3 default and
+			// fails with the range error downstream
+			if _, _, startRow, _, err := parseRange(template.HeaderRange); err == nil {
+				template.HeaderLine = startRow
+			} else {
+				template.HeaderLine = 3
+			}
+		}
+		mp.templateColumns, mp.templatePlaceholders, mp.templateErr =
+			mp.ExtractSQLDataFromExcel(template.FilePath, template.SheetName, template.HeaderRange, template.HeaderLine)
+	})
+	if mp.templateErr != nil {
+		return nil, 0, mp.templateErr
+	}
+	// Hand each caller its own copy so one caller mutating the slice cannot
+	// corrupt the cached template
+	columns := make([]string, len(mp.templateColumns))
+	copy(columns, mp.templateColumns)
+	return columns, mp.templatePlaceholders, nil
+}
+
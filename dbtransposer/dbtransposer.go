@@ -1,13 +1,18 @@
 package dbtransposer
 
 import (
+	"context"
 	"data-ingestor/config"
 	"data-ingestor/mapreduce"
+	"data-ingestor/util"
 	"database/sql"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type TransposerFunctionsInterface interface {
@@ -19,18 +24,344 @@ type TransposerFunctionsInterface interface {
 	ExtractSQLDataUsingSchema(record map[string]interface{}) ([]string, [][]interface{}, error)
 
 	ExtractSQLDataFromExcel(filePath, sheetName, rangeSpec string, line int) ([]string, int, error)
+	ExtractSQLDataFromTemplate() ([]string, int, error)
+
+	CheckTableExists(db *sql.DB, tableName string) error
+	TruncateBeforeInsert(db *sql.DB, tableName string) error
+	CreateTableFromColumns(db *sql.DB, tableName string, columns []string) error
+	LoadSchemaFromDB(ctx context.Context, tableName string) ([]string, error)
 
 	// ProcessMapResults is the Reducer function
-	ProcessMapResults(results []mapreduce.MapResult) error
+	ProcessMapResults(results []mapreduce.MapResult) (mapreduce.StreamResult, error)
+	// ProcessMapResultStream is the streaming Reducer used with a bounded transaction window
+	ProcessMapResultStream(result mapreduce.MapResult) error
 }
 
 type TransposerFunctions struct {
 	CONFIG *config.Config
 	Logger *zap.Logger
+
+	// DB is the shared connection pool, used by schema loading helpers that query
+	// the database directly (e.g. LoadSchemaFromDB).
+	DB *sql.DB
+
+	// UnicodeNormalization, when set to "NFC", "NFD", "NFKC", or "NFKD", normalizes string
+	// values to that form before insertion so differently-normalized source text does not
+	// produce duplicate-looking values. Empty means no normalization.
+	UnicodeNormalization string
+	// NormalizeColumns restricts normalization to the listed columns. Empty means all
+	// string values are normalized.
+	NormalizeColumns []string
+
+	// ColumnTransformers maps column names to value transformers run before
+	// normalization and type coercion, cleaning source data (whitespace, casing,
+	// date formats) before it reaches the database. A transformer error rejects
+	// the record with a per-column CoercionError. Register with RegisterTransformer.
+	ColumnTransformers map[string]func(interface{}) (interface{}, error)
+
+	// MaskedColumns maps column names to the mask applied to their values after
+	// transformation but before SQL generation, so PII (emails, phone numbers,
+	// SSNs) never reaches the database in plain text. Built-in masks live in
+	// masks.go; the -mask-columns flag populates this map.
+	MaskedColumns map[string]MaskFunc
+
+	// ValidationRules maps column names to the validation rules run on their
+	// values during extraction. All failures for a record are collected into a
+	// RecordValidationError and the record is skipped, so bad data surfaces as a
+	// clear per-column report instead of an opaque database error.
+	ValidationRules map[string][]ValidationRule
+
+	// TypeMapping maps column names to target Go type names ("int64", "float64", "bool",
+	// "time.RFC3339", "string"). Values for mapped columns are coerced before insertion so
+	// string values from XML/JSON can satisfy typed database columns.
+	TypeMapping map[string]string
+
+	// TemplateColumns is the full schema column list (from the Excel template or
+	// information_schema). When set, columns missing from a record map are filled from
+	// DefaultValues or with nil instead of producing a short INSERT.
+	TemplateColumns []string
+	// DefaultValues supplies fallback values for schema columns absent from a record map.
+	DefaultValues map[string]interface{}
+	// NotNullColumns lists schema columns that must not be filled with nil. A record
+	// missing one of them (with no default) fails with ErrMissingRequiredColumn.
+	NotNullColumns []string
+
+	// SkipDuplicates appends ON CONFLICT DO NOTHING to generated INSERTs so duplicate
+	// deliveries are skipped instead of failing the batch. Skipped rows are counted on
+	// Counter when one is attached.
+	SkipDuplicates bool
+	// Counter, when set, receives skipped-row counts from duplicate-skipping inserts.
+	Counter *util.Counter
+
+	// IdentifierColumn names the record key (e.g. json_hash or local_id) used to
+	// identify a failing record in insert errors and logs, so operators can grep
+	// the source file for it. Records missing the key fall back to their ordinal
+	// position in the insert sequence.
+	IdentifierColumn string
+	// recordSeq numbers insert attempts for the ordinal fallback identifier.
+	recordSeq uint64
+
+	// PlaceholderStyle overrides the driver-derived placeholder style: "dollar" for
+	// PostgreSQL-style $N, "question" for MySQL/SQLite-style ?. Empty falls back to
+	// the style registered for CONFIG.DB.DBDriver.
+	PlaceholderStyle string
+
+	// OnBatchCommit, when set, is invoked after each successful batch commit with the
+	// source-file record indexes the batch covered, so callers can checkpoint
+	// committed progress for crash recovery. It is called from the reduce phase,
+	// which processes results serially.
+	OnBatchCommit func(recordIndexes []int)
+
+	// DeadLetter, when set, receives records rejected during extraction (coercion
+	// or validation failures) and records whose INSERT failed, so they can be
+	// fixed and re-submitted.
+	DeadLetter *util.DeadLetterWriter
+
+	// ScriptWriter, when set, switches inserts to dry-run mode: generated INSERT
+	// statements are rendered into a SQL script for review instead of executed.
+	ScriptWriter *SQLScriptWriter
+
+	// Enrichments resolves source-column codes to database values (e.g. a
+	// location code to its foreign key ID) via lookup queries before the INSERT
+	// is built. Results are cached per spec in an LRU cache.
+	Enrichments []EnrichmentSpec
+	// enrichmentCache memoizes lookup results keyed by "specIndex:value", built
+	// lazily on the first enriched record.
+	enrichmentCache     *util.LRUCache
+	enrichmentCacheOnce sync.Once
+
+	// templateOnce memoizes the first ExtractSQLDataFromTemplate parse so the
+	// workbook is opened once per run instead of once per caller.
+	templateOnce         sync.Once
+	templateColumns      []string
+	templatePlaceholders int
+	templateErr          error
+
+	// AuditColumns maps column names to producers whose values are injected into
+	// every record before SQL generation (overriding any incoming value), so each
+	// row carries insert metadata like created_at and inserted_by that the source
+	// data does not provide. Start from DefaultAuditColumns or register entries
+	// with RegisterAuditColumn.
+	AuditColumns map[string]AuditColumnFunc
+
+	// SoftDeleteColumn and SoftDeleteValue recognize tombstone records from
+	// systems that signal deletions by re-sending the record with a marker field
+	// (e.g. deleted=true): a record whose SoftDeleteColumn renders as
+	// SoftDeleteValue issues a DELETE by PKColumn instead of an INSERT. Deletes
+	// are counted separately on Counter.
+	SoftDeleteColumn string
+	SoftDeleteValue  string
+	// PKColumn is the primary key column the tombstone DELETE matches on.
+	PKColumn string
+
+	// InsertCondition, when set, is evaluated before SQL generation; records it
+	// rejects are skipped without error and counted on Counter as skipped. Build
+	// one from an inline JSON rule with BuildConditionFromJSON.
+	InsertCondition InsertCondition
+
+	// PreInsertHook, when set, runs on each record before SQL generation and may
+	// return a mutated record to insert instead (e.g. stamping a batch_id). A
+	// non-nil error fails the record's batch.
+	PreInsertHook func(ctx context.Context, tableName string, record map[string]interface{}) (map[string]interface{}, error)
+	// PostInsertHook, when set, runs after the INSERT is executed, successful or
+	// not (e.g. publishing an event). It observes the outcome but cannot change it.
+	PostInsertHook func(ctx context.Context, tableName string, record map[string]interface{}, err error)
+
+	// TableRouter, when set, routes each record to a table derived from its field
+	// values instead of the fixed table the run was invoked with. It takes
+	// precedence over ModelTables routing; a router returning "" keeps the
+	// current table. Build one from a rule file with BuildTableRouter.
+	TableRouter TableRouter
+
+	// ModelTables routes multi-model records (tagged with fileloader.ModelKey) to the
+	// table configured for their model. Models without an entry fall back to the
+	// table name the run was invoked with.
+	ModelTables map[string]string
+
+	// KeyColumnMapping renames source record keys to database columns before
+	// extraction, per model (model → sourceKey → dbColumn). An entry under ""
+	// is the default mapping for models without their own; keys without an
+	// entry pass through unchanged. Populate with LoadKeyColumnMapping.
+	KeyColumnMapping map[string]map[string]string
+
+	// modelCounts tracks successful inserts per model for the final summary.
+	modelCountsMu sync.Mutex
+	modelCounts   map[string]int
+}
+
+// RegisterTransformer attaches a value transformer to the named column,
+// initializing the transformer map on first use.
+func (mp *TransposerFunctions) RegisterTransformer(column string, fn func(interface{}) (interface{}, error)) {
+	if mp.ColumnTransformers == nil {
+		mp.ColumnTransformers = map[string]func(interface{}) (interface{}, error){}
+	}
+	mp.ColumnTransformers[column] = fn
+}
+
+// recordIdentifier renders the record's configured identifier (e.g.
+// "json_hash=abc123") for error messages and log fields, so a failing record can
+// be located in the source file. When the identifier column is unset or missing
+// from the record, it falls back to the record's ordinal position in the insert
+// sequence.
+func (mp *TransposerFunctions) recordIdentifier(record map[string]interface{}) string {
+	ordinal := atomic.AddUint64(&mp.recordSeq, 1) - 1
+	if mp.IdentifierColumn != "" {
+		if value, ok := record[mp.IdentifierColumn]; ok && value != nil {
+			return fmt.Sprintf("%s=%v", mp.IdentifierColumn, value)
+		}
+	}
+	return fmt.Sprintf("record-index=%d", ordinal)
+}
+
+// recordModelInsert adds to the per-model insert count.
+func (mp *TransposerFunctions) recordModelInsert(model string, count int) {
+	mp.modelCountsMu.Lock()
+	defer mp.modelCountsMu.Unlock()
+	if mp.modelCounts == nil {
+		mp.modelCounts = map[string]int{}
+	}
+	mp.modelCounts[model] += count
+}
+
+// ModelCounts returns a copy of the per-model insert counts.
+func (mp *TransposerFunctions) ModelCounts() map[string]int {
+	mp.modelCountsMu.Lock()
+	defer mp.modelCountsMu.Unlock()
+	counts := make(map[string]int, len(mp.modelCounts))
+	for model, count := range mp.modelCounts {
+		counts[model] = count
+	}
+	return counts
 }
 
 var _ TransposerFunctionsInterface = (*TransposerFunctions)(nil)
 
+// ErrMissingRequiredColumn is returned when a record lacks a NOT NULL schema column and
+// no default value is configured for it.
+var ErrMissingRequiredColumn = errors.New("record is missing a required column")
+
+// ErrTruncateSafetyNotConfirmed is returned when -truncate is requested without
+// -confirm-truncate echoing the exact target table name.
+var ErrTruncateSafetyNotConfirmed = errors.New("truncate not confirmed: -confirm-truncate must match the -table name")
+
+// PlaceholderFunc renders the SQL parameter placeholder for a 1-based index.
+type PlaceholderFunc func(index int) string
+
+// placeholderStyles maps driver names to their placeholder style. Postgres-family
+// drivers use $n; MySQL/MariaDB and SQLite use ?; Oracle uses :n. New drivers can
+// be added via RegisterPlaceholderStyle.
+var placeholderStyles = map[string]PlaceholderFunc{
+	"pgx":      func(index int) string { return fmt.Sprintf("$%d", index) },
+	"postgres": func(index int) string { return fmt.Sprintf("$%d", index) },
+	"mysql":    func(index int) string { return "?" },
+	"mariadb":  func(index int) string { return "?" },
+	"sqlite":   func(index int) string { return "?" },
+	"sqlite3":  func(index int) string { return "?" },
+	"oracle":   func(index int) string { return fmt.Sprintf(":%d", index) },
+	"godror":   func(index int) string { return fmt.Sprintf(":%d", index) },
+}
+
+// RegisterPlaceholderStyle adds (or replaces) the placeholder style for a driver name.
+func RegisterPlaceholderStyle(driver string, fn PlaceholderFunc) {
+	placeholderStyles[strings.ToLower(driver)] = fn
+}
+
+// quoteColumn renders a column name in the one quoting style every extraction
+// path and INSERT builder uses: double-quoted with embedded double quotes
+// doubled. Centralizing it here keeps the paths interchangeable — mixing quoted
+// and bare names in one statement breaks the INSERT.
+func quoteColumn(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteTableName quotes a possibly schema-qualified table name one segment at a
+// time, so "public.loads" renders as "public"."loads".
+func quoteTableName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quoteColumn(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// formatPlaceholder renders the placeholder for the given 1-based index. An explicit
+// PlaceholderStyle wins; otherwise the style is derived from the configured DB driver.
+func (mp *TransposerFunctions) formatPlaceholder(index int) string {
+	switch mp.PlaceholderStyle {
+	case "dollar":
+		return fmt.Sprintf("$%d", index)
+	case "question":
+		return "?"
+	}
+	return mp.placeholder(index)
+}
+
+// placeholder renders the placeholder for the given 1-based index in the style of the
+// configured DB driver, defaulting to Postgres-style $n for unknown drivers.
+func (mp *TransposerFunctions) placeholder(index int) string {
+	if mp.CONFIG != nil {
+		if fn, ok := placeholderStyles[strings.ToLower(mp.CONFIG.DB.DBDriver)]; ok {
+			return fn(index)
+		}
+	}
+	return fmt.Sprintf("$%d", index)
+}
+
+// ParseTxIsolation converts a RuntimeConfig TX_ISOLATION string into the matching
+// sql.IsolationLevel. An empty string means "use the database default" and yields
+// sql.LevelDefault; any other unrecognized value is an error so typos fail loudly.
+func ParseTxIsolation(s string) (sql.IsolationLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return sql.LevelDefault, nil
+	case "read-committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable-read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("unsupported transaction isolation level %q (expected read-committed, repeatable-read, or serializable)", s)
+	}
+}
+
+// TransposerOption customizes a TransposerFunctions built by NewTransposerFunctions.
+type TransposerOption func(*TransposerFunctions)
+
+// WithDefaultValues sets the fallback values used for schema columns absent from a
+// record map.
+func WithDefaultValues(m map[string]interface{}) TransposerOption {
+	return func(mp *TransposerFunctions) {
+		mp.DefaultValues = m
+	}
+}
+
+// WithPreInsertHook sets the hook run on each record before SQL generation; its
+// returned record is the one inserted.
+func WithPreInsertHook(hook func(ctx context.Context, tableName string, record map[string]interface{}) (map[string]interface{}, error)) TransposerOption {
+	return func(mp *TransposerFunctions) {
+		mp.PreInsertHook = hook
+	}
+}
+
+// WithPostInsertHook sets the hook run after each record's INSERT is executed,
+// successful or not.
+func WithPostInsertHook(hook func(ctx context.Context, tableName string, record map[string]interface{}, err error)) TransposerOption {
+	return func(mp *TransposerFunctions) {
+		mp.PostInsertHook = hook
+	}
+}
+
+// NewTransposerFunctions constructs a TransposerFunctions with the given config and
+// logger, applying any options.
+func NewTransposerFunctions(cfg *config.Config, logger *zap.Logger, opts ...TransposerOption) *TransposerFunctions {
+	mp := &TransposerFunctions{CONFIG: cfg, Logger: logger}
+	for _, opt := range opts {
+		opt(mp)
+	}
+	return mp
+}
+
 // InsertRecords inserts records into the specified database table.
 // It accepts a database transaction, table name, and an object containing the data to be inserted.
 // The function dynamically constructs the SQL query based on the object's fields and values.
@@ -59,7 +390,7 @@ func (mp *TransposerFunctions) InsertRecords(tx *sql.Tx, tableName string, obj i
 	// Build the base INSERT query with the table name and columns
 	query := fmt.Sprintf(
 		`INSERT INTO %s (%s) VALUES `,
-		tableName,
+		quoteTableName(tableName),
 		strings.Join(columns, ", "),
 	)
 
@@ -76,8 +407,8 @@ func (mp *TransposerFunctions) InsertRecords(tx *sql.Tx, tableName string, obj i
 		// Create a slice for placeholders for the current row
 		rowPlaceholders := []string{}
 		for range row {
-			// Generate placeholder strings (e.g., $1, $2, ...)
-			rowPlaceholders = append(rowPlaceholders, fmt.Sprintf("$%d", placeholderIndex))
+			// Generate placeholder strings in the configured driver's style ($n or ?)
+			rowPlaceholders = append(rowPlaceholders, mp.formatPlaceholder(placeholderIndex))
 			placeholderIndex++
 		}
 
@@ -101,7 +432,7 @@ func (mp *TransposerFunctions) InsertRecords(tx *sql.Tx, tableName string, obj i
 	mp.Logger.Info("All Values to Execute in SQL", zap.Any("All Values", allValues))
 
 	// Execute the SQL query with the collected values
-	_, err = tx.Exec(query, allValues...)
+	result, err := tx.Exec(query, allValues...)
 	if err != nil {
 		// Log and return an error if query execution fails
 		mp.Logger.Error("Failed to execute SQL query",
@@ -111,6 +442,15 @@ func (mp *TransposerFunctions) InsertRecords(tx *sql.Tx, tableName string, obj i
 		return fmt.Errorf("failed to insert records: %w", err)
 	}
 
+	// Count the rows the database actually wrote rather than the records fed in
+	if affected, raErr := result.RowsAffected(); raErr == nil {
+		if mp.Counter != nil {
+			mp.Counter.IncrementRowsInserted(int(affected))
+		}
+	} else {
+		mp.Logger.Warn("Could not determine rows affected", zap.Error(raErr))
+	}
+
 	// Log successful execution of the SQL query
 	mp.Logger.Info("Successfully executed SQL query",
 		zap.String("query", query),
@@ -242,7 +582,7 @@ func (mp *TransposerFunctions) ExtractSQLData(record interface{}) ([]string, [][
 
 					// Match slice field with the column index and set value
 					for colIdx, colName := range columns {
-						if colName == fmt.Sprintf(`"%s"`, sliceDBTag) {
+						if colName == quoteColumn(sliceDBTag) {
 							row[colIdx] = elementValue.Field(k).Interface()
 							break
 						}
@@ -256,7 +596,7 @@ func (mp *TransposerFunctions) ExtractSQLData(record interface{}) ([]string, [][
 			if dbTag == "-" || dbTag == "" {
 				continue // Skip fields without a valid "db" tag
 			}
-			columns = append(columns, fmt.Sprintf(`"%s"`, dbTag))
+			columns = append(columns, quoteColumn(dbTag))
 			baseRow = append(baseRow, value.Interface())
 		}
 	}
@@ -286,8 +626,9 @@ func (mp *TransposerFunctions) ExtractSQLData(record interface{}) ([]string, [][
 // - results: A slice of MapResult objects containing the results of the map phase.
 //
 // Returns:
-// - An error if any transactions failed or if committing a transaction fails.
-func (mp *TransposerFunctions) ProcessMapResults(results []mapreduce.MapResult) error {
+// - A StreamResult carrying the per-worker outcomes (totals and duration are filled
+//   in by the mapreduce layer), and an error if any transactions failed or a commit fails.
+func (mp *TransposerFunctions) ProcessMapResults(results []mapreduce.MapResult) (mapreduce.StreamResult, error) {
 	// Preemptively check for errors or nil transactions in the map results
 	hasError := false
 
@@ -327,6 +668,7 @@ func (mp *TransposerFunctions) ProcessMapResults(results []mapreduce.MapResult)
 						zap.Error(err),
 					)
 				} else {
+					util.Metrics.BatchRolledBack()
 					// Log success if the rollback completes
 					mp.Logger.Info("Transaction rolled back successfully",
 						zap.Int("Worker ID", result.BatchID),
@@ -335,7 +677,7 @@ func (mp *TransposerFunctions) ProcessMapResults(results []mapreduce.MapResult)
 			}
 		}
 		// Return an error indicating that the map phase encountered issues
-		return fmt.Errorf("map phase completed with errors; all transactions rolled back")
+		return mapreduce.StreamResult{WorkerResults: results}, fmt.Errorf("map phase completed with errors; all transactions rolled back")
 	}
 
 	// Commit all transactions if no errors are found
@@ -349,17 +691,79 @@ func (mp *TransposerFunctions) ProcessMapResults(results []mapreduce.MapResult)
 					zap.Error(err),
 				)
 				// Return an error indicating that a commit failed
-				return fmt.Errorf("failed to commit transaction for batch %d: %w", result.BatchID, err)
+				return mapreduce.StreamResult{WorkerResults: results}, fmt.Errorf("failed to commit transaction for batch %d: %w", result.BatchID, err)
 			}
 
+			util.Metrics.BatchCommitted()
 			// Log success if the commit completes
 			mp.Logger.Info("Transaction committed successfully",
 				zap.Int("Worker ID", result.BatchID),
 			)
+			// Report the committed record range so progress can be checkpointed
+			if mp.OnBatchCommit != nil && len(result.RecordIndexes) > 0 {
+				mp.OnBatchCommit(result.RecordIndexes)
+			}
 		}
 	}
 	// Log a summary indicating all transactions were committed successfully
 	mp.Logger.Info("All transactions committed successfully")
+	return mapreduce.StreamResult{WorkerResults: results}, nil
+}
+
+// ProcessMapResultStream commits or rolls back a single worker transaction as soon as it
+// completes. It is the streaming counterpart to ProcessMapResults, used with
+// mapreduce.MapReduceStreamingWindowed so transactions are closed promptly instead of
+// accumulating until the end of the run.
+//
+// Parameters:
+// - result: The MapResult for one completed worker batch.
+//
+// Returns:
+// - An error if the batch failed (after rollback) or the commit fails.
+func (mp *TransposerFunctions) ProcessMapResultStream(result mapreduce.MapResult) error {
+	if result.Tx == nil {
+		// Log an error if the transaction was never started
+		mp.Logger.Error("Failed to start a transaction",
+			zap.Int("Worker ID", result.BatchID),
+			zap.Error(result.Err),
+		)
+		return fmt.Errorf("batch %d failed to start a transaction: %w", result.BatchID, result.Err)
+	}
+
+	if result.Err != nil {
+		// Roll back this batch immediately; other batches are unaffected
+		mp.Logger.Error("Transaction encountered an error. Rolling back batch.",
+			zap.Int("Worker ID", result.BatchID),
+			zap.Error(result.Err),
+		)
+		if err := result.Tx.Rollback(); err != nil {
+			mp.Logger.Error("Failed to rollback transaction",
+				zap.Int("Worker ID", result.BatchID),
+				zap.Error(err),
+			)
+		} else {
+			util.Metrics.BatchRolledBack()
+		}
+		return fmt.Errorf("batch %d rolled back: %w", result.BatchID, result.Err)
+	}
+
+	// Commit the successful batch promptly to free its connection
+	if err := result.Tx.Commit(); err != nil {
+		mp.Logger.Error("Failed to commit transaction",
+			zap.Int("Worker ID", result.BatchID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to commit transaction for batch %d: %w", result.BatchID, err)
+	}
+
+	util.Metrics.BatchCommitted()
+	mp.Logger.Info("Transaction committed successfully",
+		zap.Int("Worker ID", result.BatchID),
+	)
+	// Report the committed record range so progress can be checkpointed
+	if mp.OnBatchCommit != nil && len(result.RecordIndexes) > 0 {
+		mp.OnBatchCommit(result.RecordIndexes)
+	}
 	return nil
 }
 
@@ -0,0 +1,205 @@
+package dbtransposer
+
+import (
+	"data-ingestor/config"
+	"data-ingestor/util"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// stubDriver is a minimal database/sql driver standing in for a live
+// PostgreSQL connection: every connection records the queries it executes and
+// fails Exec with execErr when the query contains failSubstring.
+type stubDriver struct{ conn *stubConn }
+
+func (d *stubDriver) Open(string) (driver.Conn, error) { return d.conn, nil }
+
+type stubConn struct {
+	execErr       error
+	failSubstring string
+	queries       []string
+	// affectedRows is the RowsAffected every successful Exec reports, letting
+	// tests simulate ON CONFLICT DO NOTHING dropping some rows.
+	affectedRows int64
+}
+
+func (c *stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported by the stub driver")
+}
+
+func (c *stubConn) Close() error { return nil }
+
+func (c *stubConn) Begin() (driver.Tx, error) { return stubTx{}, nil }
+
+func (c *stubConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	c.queries = append(c.queries, query)
+	if c.execErr != nil && strings.Contains(query, c.failSubstring) {
+		return nil, c.execErr
+	}
+	return driver.RowsAffected(c.affectedRows), nil
+}
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+// stubDriverSeq makes registered driver names unique, since database/sql
+// panics on duplicate registration.
+var stubDriverSeq atomic.Uint64
+
+// openStubDB registers a stub driver around conn and opens a *sql.DB on it.
+func openStubDB(t *testing.T, conn *stubConn) *sql.DB {
+	t.Helper()
+	name := fmt.Sprintf("stub-%d", stubDriverSeq.Add(1))
+	sql.Register(name, &stubDriver{conn: conn})
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("opening stub database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestInsertRecordsUsingSchemaMySQLPlaceholders(t *testing.T) {
+	conn := &stubConn{}
+	db := openStubDB(t, conn)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("beginning stub transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	mp := &TransposerFunctions{
+		Logger:          zap.NewNop(),
+		CONFIG:          &config.Config{DB: config.DatabaseConfig{DBDriver: "mysql"}},
+		TemplateColumns: []string{"name", "count"},
+	}
+	if err := mp.InsertRecordsUsingSchema(tx, "loads", map[string]interface{}{"name": "a", "count": 1}); err != nil {
+		t.Fatalf("InsertRecordsUsingSchema: %v", err)
+	}
+
+	var insert string
+	for _, query := range conn.queries {
+		if strings.HasPrefix(query, "INSERT") {
+			insert = query
+		}
+	}
+	if insert == "" {
+		t.Fatalf("no INSERT executed; queries = %q", conn.queries)
+	}
+	// MySQL uses ? placeholders, never the Postgres $n style
+	if !strings.Contains(insert, "(?, ?)") {
+		t.Errorf("INSERT = %q, want ? placeholders for the mysql driver", insert)
+	}
+	if strings.Contains(insert, "$1") {
+		t.Errorf("INSERT = %q, must not use $n placeholders for the mysql driver", insert)
+	}
+}
+
+func TestCheckTableExistsMissingTable(t *testing.T) {
+	conn := &stubConn{
+		execErr:       errors.New(`pq: relation "missing_table" does not exist`),
+		failSubstring: "missing_table",
+	}
+	db := openStubDB(t, conn)
+	mp := &TransposerFunctions{Logger: zap.NewNop()}
+
+	err := mp.CheckTableExists(db, "missing_table")
+	if err == nil {
+		t.Fatal("expected an error for a missing table")
+	}
+	if !strings.Contains(err.Error(), `table "missing_table" does not exist`) {
+		t.Errorf("error = %q, want it to name the missing table", err)
+	}
+}
+
+func TestCheckTableExistsPresentTable(t *testing.T) {
+	conn := &stubConn{}
+	db := openStubDB(t, conn)
+	mp := &TransposerFunctions{Logger: zap.NewNop()}
+
+	if err := mp.CheckTableExists(db, "public.loads"); err != nil {
+		t.Fatalf("CheckTableExists: %v", err)
+	}
+	if len(conn.queries) != 1 {
+		t.Fatalf("executed %d queries, want 1 probe", len(conn.queries))
+	}
+	// The probe must quote the (schema-qualified) table name per segment
+	if !strings.Contains(conn.queries[0], `"public"."loads"`) {
+		t.Errorf("probe query = %q, want the quoted table name", conn.queries[0])
+	}
+}
+
+func TestSkipDuplicatesCountsInsertedAndSkippedRows(t *testing.T) {
+	// The database reports 2 of 3 rows written: ON CONFLICT DO NOTHING
+	// dropped the third as a duplicate
+	conn := &stubConn{affectedRows: 2}
+	db := openStubDB(t, conn)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("beginning stub transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	counter := &util.Counter{}
+	mp := &TransposerFunctions{
+		Logger:          zap.NewNop(),
+		TemplateColumns: []string{"name"},
+		SkipDuplicates:  true,
+		Counter:         counter,
+	}
+	batch := []map[string]interface{}{{"name": "a"}, {"name": "b"}, {"name": "a"}}
+	if err := mp.InsertRecordsBatch(tx, "loads", batch); err != nil {
+		t.Fatalf("InsertRecordsBatch: %v", err)
+	}
+
+	var insert string
+	for _, query := range conn.queries {
+		if strings.HasPrefix(query, "INSERT") {
+			insert = query
+		}
+	}
+	if !strings.HasSuffix(insert, "ON CONFLICT DO NOTHING") {
+		t.Errorf("INSERT = %q, want an ON CONFLICT DO NOTHING suffix", insert)
+	}
+	if got := counter.GetRowsInserted(); got != 2 {
+		t.Errorf("GetRowsInserted = %d, want 2 (what the database reported)", got)
+	}
+	if got := counter.GetSkipped(); got != 1 {
+		t.Errorf("GetSkipped = %d, want 1 (rows minus rows affected)", got)
+	}
+}
+
+func TestRowsInsertedTracksRowsAffectedWithoutSkipDuplicates(t *testing.T) {
+	conn := &stubConn{affectedRows: 1}
+	db := openStubDB(t, conn)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("beginning stub transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	counter := &util.Counter{}
+	mp := &TransposerFunctions{
+		Logger:          zap.NewNop(),
+		TemplateColumns: []string{"name"},
+		Counter:         counter,
+	}
+	if err := mp.InsertRecordsUsingSchema(tx, "loads", map[string]interface{}{"name": "a"}); err != nil {
+		t.Fatalf("InsertRecordsUsingSchema: %v", err)
+	}
+
+	if got := counter.GetRowsInserted(); got != 1 {
+		t.Errorf("GetRowsInserted = %d, want 1", got)
+	}
+	if got := counter.GetSkipped(); got != 0 {
+		t.Errorf("GetSkipped = %d, want 0 when SkipDuplicates is off", got)
+	}
+}
@@ -0,0 +1,120 @@
+package dbtransposer
+
+import (
+	"data-ingestor/util"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"os"
+)
+
+// enrichmentCacheSize bounds the per-run lookup cache; lookup tables are
+// typically small, so hits dominate well below this.
+const enrichmentCacheSize = 10000
+
+// ErrEnrichmentFailed is returned when a required enrichment lookup matches no
+// rows, meaning the record's code cannot be resolved to a database value.
+var ErrEnrichmentFailed = errors.New("enrichment lookup returned no rows")
+
+// EnrichmentSpec describes one lookup-table enrichment: LookupSQL is executed
+// with the record's SourceColumn value as its single bind parameter, and the
+// returned row's values are merged into the record under TargetColumns (in
+// select-list order). When Required is true, a lookup that matches no rows
+// rejects the record; otherwise the record passes through unenriched.
+type EnrichmentSpec struct {
+	SourceColumn  string   `json:"sourceColumn"`
+	LookupSQL     string   `json:"lookupSql"`
+	TargetColumns []string `json:"targetColumns"`
+	Required      bool     `json:"required"`
+}
+
+// LoadEnrichmentSpecs reads enrichment specs from a JSON file (an array of
+// EnrichmentSpec objects) and validates that each names a source column, a
+// lookup query, and at least one target column.
+func LoadEnrichmentSpecs(path string) ([]EnrichmentSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read enrichments file %q: %w", path, err)
+	}
+
+	var specs []EnrichmentSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse enrichments file %q: %w", path, err)
+	}
+
+	for i, spec := range specs {
+		if spec.SourceColumn == "" {
+			return nil, fmt.Errorf("enrichment %d: sourceColumn is required", i)
+		}
+		if spec.LookupSQL == "" {
+			return nil, fmt.Errorf("enrichment %d: lookupSql is required", i)
+		}
+		if len(spec.TargetColumns) == 0 {
+			return nil, fmt.Errorf("enrichment %d: targetColumns must name at least one column", i)
+		}
+	}
+	return specs, nil
+}
+
+// applyEnrichments runs every configured enrichment against the record, merging
+// looked-up values into it in place. Lookup results are memoized in an LRU cache
+// keyed by "specIndex:value" so repeated codes hit the database once. A missing
+// or nil source column, or a lookup with no rows, skips the spec unless it is
+// marked Required, in which case the record is rejected with ErrEnrichmentFailed.
+func (mp *TransposerFunctions) applyEnrichments(record map[string]interface{}) error {
+	mp.enrichmentCacheOnce.Do(func() {
+		mp.enrichmentCache = util.NewLRUCache(enrichmentCacheSize)
+	})
+
+	for i, spec := range mp.Enrichments {
+		value, ok := record[spec.SourceColumn]
+		if !ok || value == nil {
+			if spec.Required {
+				return fmt.Errorf("%w: record has no value for source column %q", ErrEnrichmentFailed, spec.SourceColumn)
+			}
+			continue
+		}
+
+		cacheKey := fmt.Sprintf("%d:%v", i, value)
+		if cached, hit := mp.enrichmentCache.Get(cacheKey); hit {
+			for column, looked := range cached.(map[string]interface{}) {
+				record[column] = looked
+			}
+			continue
+		}
+
+		// Scan the lookup row into one value per target column, in select-list order
+		dest := make([]interface{}, len(spec.TargetColumns))
+		ptrs := make([]interface{}, len(spec.TargetColumns))
+		for j := range dest {
+			ptrs[j] = &dest[j]
+		}
+		err := mp.DB.QueryRow(spec.LookupSQL, value).Scan(ptrs...)
+		if errors.Is(err, sql.ErrNoRows) {
+			if spec.Required {
+				mp.Logger.Error("Required enrichment lookup matched no rows",
+					zap.String("sourceColumn", spec.SourceColumn),
+					zap.Any("value", value))
+				return fmt.Errorf("%w: no match for %s=%v", ErrEnrichmentFailed, spec.SourceColumn, value)
+			}
+			continue
+		}
+		if err != nil {
+			mp.Logger.Error("Enrichment lookup failed",
+				zap.String("sourceColumn", spec.SourceColumn),
+				zap.Any("value", value),
+				zap.Error(err))
+			return fmt.Errorf("enrichment lookup for %s=%v failed: %w", spec.SourceColumn, value, err)
+		}
+
+		looked := make(map[string]interface{}, len(spec.TargetColumns))
+		for j, column := range spec.TargetColumns {
+			looked[column] = dest[j]
+			record[column] = dest[j]
+		}
+		mp.enrichmentCache.Put(cacheKey, looked)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package dbtransposer
+
+import (
+	"data-ingestor/config"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+// writeTemplateWorkbook writes cells (cell reference -> value) to the given
+// sheet of a fresh workbook and returns its path.
+func writeTemplateWorkbook(t *testing.T, sheetName string, cells map[string]string) string {
+	t.Helper()
+	file := excelize.NewFile()
+	if sheetName != "Sheet1" {
+		if _, err := file.NewSheet(sheetName); err != nil {
+			t.Fatalf("creating sheet %q: %v", sheetName, err)
+		}
+	}
+	for ref, value := range cells {
+		if err := file.SetCellValue(sheetName, ref, value); err != nil {
+			t.Fatalf("setting cell %s: %v", ref, err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "template.xlsx")
+	if err := file.SaveAs(path); err != nil {
+		t.Fatalf("saving workbook: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("closing workbook: %v", err)
+	}
+	return path
+}
+
+func TestExtractSQLDataFromExcelNarrowRange(t *testing.T) {
+	path := writeTemplateWorkbook(t, "Sheet1", map[string]string{
+		"A3": "c1", "B3": "c2", "C3": "c3", "D3": "c4", "E3": "c5",
+	})
+	mp := &TransposerFunctions{Logger: zap.NewNop()}
+
+	// A range narrower than the populated row must only return its columns
+	columns, count, err := mp.ExtractSQLDataFromExcel(path, "Sheet1", "B3:D3", 3)
+	if err != nil {
+		t.Fatalf("ExtractSQLDataFromExcel: %v", err)
+	}
+	if want := []string{"c2", "c3", "c4"}; strings.Join(columns, ",") != strings.Join(want, ",") {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+	if count != 3 {
+		t.Errorf("placeholderCount = %d, want 3", count)
+	}
+}
+
+func TestExtractSQLDataFromExcelWideRange(t *testing.T) {
+	path := writeTemplateWorkbook(t, "Sheet1", map[string]string{
+		"A3": "c1", "B3": "c2", "C3": "c3",
+	})
+	mp := &TransposerFunctions{Logger: zap.NewNop()}
+
+	// A range wider than the populated row contributes only the cells that exist
+	columns, count, err := mp.ExtractSQLDataFromExcel(path, "Sheet1", "A3:Z3", 3)
+	if err != nil {
+		t.Fatalf("ExtractSQLDataFromExcel: %v", err)
+	}
+	if want := []string{"c1", "c2", "c3"}; strings.Join(columns, ",") != strings.Join(want, ",") {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+	if count != 3 {
+		t.Errorf("placeholderCount = %d, want 3", count)
+	}
+}
+
+func TestExtractSQLDataFromExcelMultiRowRange(t *testing.T) {
+	path := writeTemplateWorkbook(t, "Sheet1", map[string]string{
+		"A1": "r1c1", "B1": "r1c2",
+		"A2": "r2c1", "B2": "r2c2",
+	})
+	mp := &TransposerFunctions{Logger: zap.NewNop()}
+
+	columns, count, err := mp.ExtractSQLDataFromExcel(path, "Sheet1", "A1:B2", 1)
+	if err != nil {
+		t.Fatalf("ExtractSQLDataFromExcel: %v", err)
+	}
+	if want := []string{"r1c1", "r1c2", "r2c1", "r2c2"}; strings.Join(columns, ",") != strings.Join(want, ",") {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+	if count != 4 {
+		t.Errorf("placeholderCount = %d, want 4", count)
+	}
+}
+
+func TestExtractSQLDataFromTemplateNonDefaultSheet(t *testing.T) {
+	path := writeTemplateWorkbook(t, "Template", map[string]string{
+		"A5": "user", "B5": "loadNumber", "C5": "decisionDate",
+	})
+	mp := &TransposerFunctions{
+		Logger: zap.NewNop(),
+		CONFIG: &config.Config{ExcelTemplate: config.ExcelTemplateConfig{
+			FilePath:    path,
+			SheetName:   "Template",
+			HeaderRange: "A5:C5",
+			// HEADER_LINE unset: derived from the range's row
+		}},
+	}
+
+	columns, count, err := mp.ExtractSQLDataFromTemplate()
+	if err != nil {
+		t.Fatalf("ExtractSQLDataFromTemplate: %v", err)
+	}
+	if want := []string{"user", "loadNumber", "decisionDate"}; strings.Join(columns, ",") != strings.Join(want, ",") {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+	if count != 3 {
+		t.Errorf("placeholderCount = %d, want 3", count)
+	}
+}
+
+func TestExtractSQLDataFromTemplateRangeBeyondSheet(t *testing.T) {
+	path := writeTemplateWorkbook(t, "Sheet1", map[string]string{"A3": "c1"})
+	mp := &TransposerFunctions{
+		Logger: zap.NewNop(),
+		CONFIG: &config.Config{ExcelTemplate: config.ExcelTemplateConfig{
+			FilePath:    path,
+			HeaderRange: "A99:K99",
+		}},
+	}
+
+	_, _, err := mp.ExtractSQLDataFromTemplate()
+	if err == nil {
+		t.Fatal("expected an error for a header range beyond the sheet's last row")
+	}
+	if !strings.Contains(err.Error(), "beyond the last row") {
+		t.Errorf("error = %q, want it to say the range is beyond the sheet bounds", err)
+	}
+}
+
+func TestExtractSQLDataFromExcelHonorsSingleRowRange(t *testing.T) {
+	path := writeTemplateWorkbook(t, "Sheet1", map[string]string{
+		"A3": "wrong1", "B3": "wrong2",
+		"A5": "right1", "B5": "right2",
+	})
+	mp := &TransposerFunctions{Logger: zap.NewNop()}
+
+	// Range and line agree on row 5: row 5 is read, not the historical line 3
+	columns, _, err := mp.ExtractSQLDataFromExcel(path, "Sheet1", "A5:B5", 5)
+	if err != nil {
+		t.Fatalf("ExtractSQLDataFromExcel: %v", err)
+	}
+	if want := []string{"right1", "right2"}; strings.Join(columns, ",") != strings.Join(want, ",") {
+		t.Errorf("columns = %v, want %v", columns, want)
+	}
+
+	// Range and line disagree: the mismatch is an error, not a silent read of
+	// the line's row
+	if _, _, err := mp.ExtractSQLDataFromExcel(path, "Sheet1", "A5:B5", 3); err == nil {
+		t.Fatal("expected an error for a range/line mismatch")
+	} else if !strings.Contains(err.Error(), "row 5") || !strings.Contains(err.Error(), "line is 3") {
+		t.Errorf("error = %q, want it to name both the range row and the line", err)
+	}
+}
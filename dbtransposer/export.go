@@ -0,0 +1,93 @@
+package dbtransposer
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// StreamTableRecords is the reverse of the ingest path: it selects the template
+// columns from the table and streams each row as a record map into recordChan,
+// so the existing exporters can write the table back out in the same column
+// layout the template defines. NULLs arrive as nil map values, which each
+// exporter already renders for its format (CSV null token, JSON null, empty
+// Excel cell). The where clause and limit are appended verbatim; like the table
+// name, they are trusted operator input, not end-user data.
+//
+// Parameters:
+//   - ctx: Context for the query.
+//   - tableName: The name of the table to export.
+//   - columns: The template columns to select, in output order.
+//   - where: Optional filter appended as a WHERE clause; empty means no filter.
+//   - limit: Optional row cap appended as a LIMIT clause; <= 0 means no cap.
+//   - recordChan: Destination channel for the streamed records; the caller closes it.
+//
+// Returns:
+//   - The number of rows streamed, or an error if the query or a row scan fails.
+func (mp *TransposerFunctions) StreamTableRecords(ctx context.Context, tableName string, columns []string, where string, limit int, recordChan chan map[string]interface{}) (int, error) {
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("cannot export table %q: no template columns provided", tableName)
+	}
+
+	// Select exactly the template columns, quoted the same way the INSERT path
+	// quotes them, so the export layout matches the ingest layout
+	quoted := make([]string, 0, len(columns))
+	for _, col := range columns {
+		quoted = append(quoted, quoteColumn(col))
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s`, strings.Join(quoted, ", "), quoteTableName(tableName))
+	if where != "" {
+		query += " WHERE " + where
+	}
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	mp.Logger.Info("Exporting table records",
+		zap.String("tableName", tableName),
+		zap.String("query", query))
+
+	rows, err := mp.DB.QueryContext(ctx, query)
+	if err != nil {
+		mp.Logger.Error("Failed to query table for export",
+			zap.String("tableName", tableName),
+			zap.Error(err))
+		return 0, fmt.Errorf("failed to query table %q for export: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		// Scan every column into interface{} slots; NULLs scan as nil
+		dest := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range dest {
+			ptrs[i] = &dest[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return count, fmt.Errorf("failed to scan export row: %w", err)
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			value := dest[i]
+			// Drivers commonly hand text columns back as []byte; convert so the
+			// exporters render readable strings instead of byte arrays
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			record[col] = value
+		}
+		recordChan <- record
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("failed to read export rows: %w", err)
+	}
+
+	mp.Logger.Info("Table export query complete",
+		zap.String("tableName", tableName),
+		zap.Int("rows", count))
+	return count, nil
+}
@@ -0,0 +1,108 @@
+package dbtransposer
+
+import (
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InferSchemaFromRecords derives a TypeMapping from the first sampleSize records,
+// so operators onboarding a new data source do not have to hand-write one. A key
+// maps to "int64" when every non-nil sampled value parses as an integer,
+// "float64" when every value parses as a number, "time.RFC3339" when every value
+// parses as an RFC3339 timestamp, and "string" otherwise. Keys whose sampled
+// values are all nil fall back to "string". The output can be saved as a
+// type-mapping config file and fed back into TypeMapping. A sampleSize of zero
+// or less inspects every record.
+func (mp *TransposerFunctions) InferSchemaFromRecords(records []map[string]interface{}, sampleSize int) map[string]string {
+	if sampleSize <= 0 || sampleSize > len(records) {
+		sampleSize = len(records)
+	}
+
+	// Track which candidate types every non-nil value of a key has satisfied
+	type candidate struct {
+		seen    bool
+		isInt   bool
+		isFloat bool
+		isTime  bool
+	}
+	candidates := map[string]*candidate{}
+	for _, record := range records[:sampleSize] {
+		for key, value := range record {
+			c, ok := candidates[key]
+			if !ok {
+				c = &candidate{isInt: true, isFloat: true, isTime: true}
+				candidates[key] = c
+			}
+			if value == nil {
+				continue
+			}
+			c.seen = true
+			c.isInt = c.isInt && parsesAsInt64(value)
+			c.isFloat = c.isFloat && parsesAsFloat64(value)
+			c.isTime = c.isTime && parsesAsRFC3339(value)
+		}
+	}
+
+	// Narrowest type wins: int64 over float64 over time.RFC3339 over string
+	mapping := make(map[string]string, len(candidates))
+	for key, c := range candidates {
+		switch {
+		case !c.seen:
+			mapping[key] = "string"
+		case c.isInt:
+			mapping[key] = "int64"
+		case c.isFloat:
+			mapping[key] = "float64"
+		case c.isTime:
+			mapping[key] = "time.RFC3339"
+		default:
+			mapping[key] = "string"
+		}
+	}
+
+	mp.Logger.Info("Inferred type mapping from sampled records",
+		zap.Int("recordsSampled", sampleSize),
+		zap.Int("columns", len(mapping)))
+	return mapping
+}
+
+// parsesAsInt64 reports whether the value satisfies the "int64" coercion.
+func parsesAsInt64(value interface{}) bool {
+	switch v := value.(type) {
+	case int, int64:
+		return true
+	case float64:
+		// JSON numbers decode as float64; whole numbers still qualify
+		return v == float64(int64(v))
+	case string:
+		_, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return err == nil
+	}
+	return false
+}
+
+// parsesAsFloat64 reports whether the value satisfies the "float64" coercion.
+func parsesAsFloat64(value interface{}) bool {
+	switch v := value.(type) {
+	case int, int64, float64:
+		return true
+	case string:
+		_, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return err == nil
+	}
+	return false
+}
+
+// parsesAsRFC3339 reports whether the value satisfies the "time.RFC3339" coercion.
+func parsesAsRFC3339(value interface{}) bool {
+	switch v := value.(type) {
+	case time.Time:
+		return true
+	case string:
+		_, err := time.Parse(time.RFC3339, strings.TrimSpace(v))
+		return err == nil
+	}
+	return false
+}
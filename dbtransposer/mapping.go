@@ -0,0 +1,88 @@
+package dbtransposer
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+)
+
+// LoadKeyColumnMapping reads a key-column mapping file of the form
+// model → sourceKey → dbColumn and validates it. Files ending in .yaml or .yml
+// are parsed as YAML; everything else is parsed as JSON. An entry under the
+// empty model name ("") acts as the default mapping for models without their
+// own entry.
+//
+// When templateColumns is non-empty, every mapped database column must appear
+// in it, so typos in the mapping file fail at startup rather than at insert
+// time. Pass nil to skip the template check (e.g. when no template is in use).
+//
+// Parameters:
+//   - path: Path to the JSON or YAML mapping file.
+//   - templateColumns: The template's column names to validate against, or nil.
+//
+// Returns:
+//   - The parsed mapping, ready to assign to TransposerFunctions.KeyColumnMapping.
+//   - An error if the file cannot be read or parsed, or a mapped column is not
+//     in the template.
+func LoadKeyColumnMapping(path string, templateColumns []string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping file %q: %w", path, err)
+	}
+
+	mapping := map[string]map[string]string{}
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &mapping); err != nil {
+			return nil, fmt.Errorf("failed to parse mapping file %q: %w", path, err)
+		}
+	default:
+		if err := json.Unmarshal(data, &mapping); err != nil {
+			return nil, fmt.Errorf("failed to parse mapping file %q: %w", path, err)
+		}
+	}
+
+	// Validate every mapped column against the template so a bad mapping fails
+	// the run up front
+	templateSet := make(map[string]struct{}, len(templateColumns))
+	for _, col := range templateColumns {
+		templateSet[col] = struct{}{}
+	}
+	for model, keys := range mapping {
+		for sourceKey, dbColumn := range keys {
+			if sourceKey == "" || dbColumn == "" {
+				return nil, fmt.Errorf("mapping for model %q has an empty source key or column", model)
+			}
+			if len(templateColumns) > 0 {
+				if _, ok := templateSet[dbColumn]; !ok {
+					return nil, fmt.Errorf("mapping for model %q maps %q to column %q, which is not in the template", model, sourceKey, dbColumn)
+				}
+			}
+		}
+	}
+	return mapping, nil
+}
+
+// applyKeyColumnMapping renames the record's source keys to their database
+// columns in place, using the model's mapping or the default ("") mapping when
+// the model has no entry. Keys without a mapping entry pass through unchanged;
+// a mapped value replaces any value already under the target column.
+func (mp *TransposerFunctions) applyKeyColumnMapping(model string, record map[string]interface{}) {
+	keys, ok := mp.KeyColumnMapping[model]
+	if !ok {
+		keys, ok = mp.KeyColumnMapping[""]
+	}
+	if !ok {
+		return
+	}
+	for sourceKey, dbColumn := range keys {
+		value, present := record[sourceKey]
+		if !present || sourceKey == dbColumn {
+			continue
+		}
+		record[dbColumn] = value
+		delete(record, sourceKey)
+	}
+}
@@ -0,0 +1,102 @@
+package dbtransposer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaskFunc obscures a sensitive value before it is handed to SQL generation.
+// Masks never fail: values they cannot interpret are fully masked rather than
+// passed through, so malformed PII does not leak.
+type MaskFunc func(interface{}) interface{}
+
+// MaskEmail keeps the first character of the local part and the domain
+// (u***@domain.com). Values without an "@" are fully masked.
+func MaskEmail(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	s := fmt.Sprintf("%v", value)
+	at := strings.LastIndex(s, "@")
+	if at < 1 {
+		return "***"
+	}
+	return s[:1] + "***" + s[at:]
+}
+
+// MaskPhone keeps the last four digits (***-***-1234). Values with fewer than
+// four digits are fully masked.
+func MaskPhone(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	digits := []rune{}
+	for _, r := range fmt.Sprintf("%v", value) {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) < 4 {
+		return "***"
+	}
+	return "***-***-" + string(digits[len(digits)-4:])
+}
+
+// MaskFull replaces the whole value with "***". Nil stays nil so NULL columns
+// round-trip.
+func MaskFull(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return "***"
+}
+
+// MaskPartial keeps the first showFirst and last showLast characters, masking
+// everything between. Values too short to keep both ends are fully masked.
+func MaskPartial(showFirst, showLast int) MaskFunc {
+	return func(value interface{}) interface{} {
+		if value == nil {
+			return nil
+		}
+		s := fmt.Sprintf("%v", value)
+		if len(s) <= showFirst+showLast {
+			return "***"
+		}
+		return s[:showFirst] + "***" + s[len(s)-showLast:]
+	}
+}
+
+// ParseMaskSpecs parses the -mask-columns notation ("email=email,user=full")
+// into a MaskedColumns map. Supported mask names: email, phone, full, and
+// partial:first:last (e.g. "user=partial:2:2").
+func ParseMaskSpecs(spec string) (map[string]MaskFunc, error) {
+	masks := map[string]MaskFunc{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid mask spec %q (expected column=mask)", pair)
+		}
+		column, maskName := parts[0], parts[1]
+		switch {
+		case maskName == "email":
+			masks[column] = MaskEmail
+		case maskName == "phone":
+			masks[column] = MaskPhone
+		case maskName == "full":
+			masks[column] = MaskFull
+		case strings.HasPrefix(maskName, "partial:"):
+			var showFirst, showLast int
+			if _, err := fmt.Sscanf(maskName, "partial:%d:%d", &showFirst, &showLast); err != nil {
+				return nil, fmt.Errorf("invalid partial mask %q (expected partial:first:last)", maskName)
+			}
+			masks[column] = MaskPartial(showFirst, showLast)
+		default:
+			return nil, fmt.Errorf("unknown mask %q for column %q (expected email, phone, full, or partial:first:last)", maskName, column)
+		}
+	}
+	return masks, nil
+}
@@ -0,0 +1,50 @@
+package dbtransposer
+
+import (
+	"go.uber.org/zap"
+	"testing"
+)
+
+// nfdName is "José" with the é in decomposed (NFD) form: e + combining acute.
+const nfdName = "Jose\u0301"
+
+// nfcName is the same text in composed (NFC) form.
+const nfcName = "Jos\u00e9"
+
+func TestNormalizeValueNFDToNFC(t *testing.T) {
+	mp := &TransposerFunctions{Logger: zap.NewNop(), UnicodeNormalization: "NFC"}
+
+	if got := mp.normalizeValue("name", nfdName); got != nfcName {
+		t.Errorf("normalizeValue = %q, want the NFC form %q", got, nfcName)
+	}
+
+	// Non-string values pass through untouched
+	if got := mp.normalizeValue("count", 7); got != 7 {
+		t.Errorf("normalizeValue(int) = %v, want 7", got)
+	}
+
+	// A configured column subset leaves other columns alone
+	mp.NormalizeColumns = []string{"other"}
+	if got := mp.normalizeValue("name", nfdName); got != nfdName {
+		t.Errorf("normalizeValue outside NormalizeColumns = %q, want the input unchanged", got)
+	}
+}
+
+func TestExtractSQLDataStoresNFC(t *testing.T) {
+	mp := &TransposerFunctions{
+		Logger:               zap.NewNop(),
+		UnicodeNormalization: "NFC",
+		TemplateColumns:      []string{"name"},
+	}
+
+	_, rows, err := mp.ExtractSQLDataUsingSchema(map[string]interface{}{"name": nfdName})
+	if err != nil {
+		t.Fatalf("ExtractSQLDataUsingSchema: %v", err)
+	}
+	if len(rows) != 1 || len(rows[0]) != 1 {
+		t.Fatalf("rows = %v, want a single value", rows)
+	}
+	if rows[0][0] != nfcName {
+		t.Errorf("extracted value = %q, want the NFC form %q", rows[0][0], nfcName)
+	}
+}
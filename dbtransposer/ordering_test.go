@@ -0,0 +1,82 @@
+package dbtransposer
+
+import (
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestExtractSQLDataUsingSchemaOrdersColumnsByTemplate(t *testing.T) {
+	mp := &TransposerFunctions{
+		Logger:          zap.NewNop(),
+		TemplateColumns: []string{"user", "loadNumber", "decisionDate"},
+	}
+
+	// The same fields assembled in different insertion orders; map iteration
+	// order must not leak into the emitted columns
+	first := map[string]interface{}{"user": "alice", "loadNumber": "1", "decisionDate": "2024-01-01"}
+	second := map[string]interface{}{"decisionDate": "2024-01-02", "user": "bob", "loadNumber": "2"}
+
+	firstColumns, firstRows, err := mp.ExtractSQLDataUsingSchema(first)
+	if err != nil {
+		t.Fatalf("ExtractSQLDataUsingSchema(first): %v", err)
+	}
+	secondColumns, secondRows, err := mp.ExtractSQLDataUsingSchema(second)
+	if err != nil {
+		t.Fatalf("ExtractSQLDataUsingSchema(second): %v", err)
+	}
+
+	want := []string{`"user"`, `"loadNumber"`, `"decisionDate"`}
+	if !reflect.DeepEqual(firstColumns, want) {
+		t.Errorf("first columns = %v, want the template order %v", firstColumns, want)
+	}
+	if !reflect.DeepEqual(firstColumns, secondColumns) {
+		t.Errorf("column lists differ between records: %v vs %v", firstColumns, secondColumns)
+	}
+
+	// Values must line up with the template order, not the map's
+	if wantRow := []interface{}{"alice", "1", "2024-01-01"}; !reflect.DeepEqual(firstRows[0], wantRow) {
+		t.Errorf("first row = %v, want %v", firstRows[0], wantRow)
+	}
+	if wantRow := []interface{}{"bob", "2", "2024-01-02"}; !reflect.DeepEqual(secondRows[0], wantRow) {
+		t.Errorf("second row = %v, want %v", secondRows[0], wantRow)
+	}
+}
+
+func TestExtractSQLDataUsingSchemaFillsMissingAndIgnoresExtraKeys(t *testing.T) {
+	mp := &TransposerFunctions{
+		Logger:          zap.NewNop(),
+		TemplateColumns: []string{"user", "loadNumber"},
+	}
+
+	record := map[string]interface{}{"user": "alice", "unexpected": "dropped"}
+	columns, rows, err := mp.ExtractSQLDataUsingSchema(record)
+	if err != nil {
+		t.Fatalf("ExtractSQLDataUsingSchema: %v", err)
+	}
+
+	if want := []string{`"user"`, `"loadNumber"`}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %v, want %v (extra keys ignored, schema columns kept)", columns, want)
+	}
+	if wantRow := []interface{}{"alice", nil}; !reflect.DeepEqual(rows[0], wantRow) {
+		t.Errorf("row = %v, want %v (nil fill for the missing schema column)", rows[0], wantRow)
+	}
+}
+
+func TestExtractSQLDataUsingSchemaSortsKeysWithoutTemplate(t *testing.T) {
+	mp := &TransposerFunctions{Logger: zap.NewNop()}
+
+	record := map[string]interface{}{"zeta": "z", "alpha": "a", "mid": "m"}
+	columns, rows, err := mp.ExtractSQLDataUsingSchema(record)
+	if err != nil {
+		t.Fatalf("ExtractSQLDataUsingSchema: %v", err)
+	}
+
+	if want := []string{`"alpha"`, `"mid"`, `"zeta"`}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("columns = %v, want the sorted key order %v", columns, want)
+	}
+	if wantRow := []interface{}{"a", "m", "z"}; !reflect.DeepEqual(rows[0], wantRow) {
+		t.Errorf("row = %v, want %v", rows[0], wantRow)
+	}
+}
@@ -0,0 +1,96 @@
+package dbtransposer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"data-ingestor/config"
+	"go.uber.org/zap"
+)
+
+// transposerForDriver builds a TransposerFunctions whose placeholder style is
+// derived from the given DB driver name.
+func transposerForDriver(driver string) *TransposerFunctions {
+	return &TransposerFunctions{
+		Logger: zap.NewNop(),
+		CONFIG: &config.Config{DB: config.DatabaseConfig{DBDriver: driver}},
+	}
+}
+
+func TestFormatPlaceholderPerDriver(t *testing.T) {
+	cases := []struct {
+		driver string
+		first  string
+		third  string
+	}{
+		{"pgx", "$1", "$3"},
+		{"postgres", "$1", "$3"},
+		{"mysql", "?", "?"},
+		{"mariadb", "?", "?"},
+		{"sqlite", "?", "?"},
+		{"sqlite3", "?", "?"},
+		{"oracle", ":1", ":3"},
+		{"godror", ":1", ":3"},
+		{"unknown-driver", "$1", "$3"}, // unknown drivers keep the Postgres default
+	}
+	for _, c := range cases {
+		mp := transposerForDriver(c.driver)
+		if got := mp.formatPlaceholder(1); got != c.first {
+			t.Errorf("formatPlaceholder(1) for %q = %q, want %q", c.driver, got, c.first)
+		}
+		if got := mp.formatPlaceholder(3); got != c.third {
+			t.Errorf("formatPlaceholder(3) for %q = %q, want %q", c.driver, got, c.third)
+		}
+	}
+}
+
+func TestFormatPlaceholderExplicitStyleWins(t *testing.T) {
+	mp := transposerForDriver("pgx")
+	mp.PlaceholderStyle = "question"
+	if got := mp.formatPlaceholder(2); got != "?" {
+		t.Errorf("formatPlaceholder with style question = %q, want ?", got)
+	}
+	mp = transposerForDriver("mysql")
+	mp.PlaceholderStyle = "dollar"
+	if got := mp.formatPlaceholder(2); got != "$2" {
+		t.Errorf("formatPlaceholder with style dollar = %q, want $2", got)
+	}
+}
+
+func TestRegisterPlaceholderStyle(t *testing.T) {
+	// Unique driver name so the global registry is not disturbed for other tests
+	RegisterPlaceholderStyle("test-at-driver", func(index int) string {
+		return fmt.Sprintf("@p%d", index)
+	})
+	mp := transposerForDriver("test-at-driver")
+	if got := mp.formatPlaceholder(2); got != "@p2" {
+		t.Errorf("formatPlaceholder for a registered style = %q, want @p2", got)
+	}
+}
+
+func TestInsertRecordsUsingSchemaPostgresPlaceholders(t *testing.T) {
+	conn := &stubConn{}
+	db := openStubDB(t, conn)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("beginning stub transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	mp := transposerForDriver("pgx")
+	mp.TemplateColumns = []string{"name", "count"}
+	if err := mp.InsertRecordsUsingSchema(tx, "loads", map[string]interface{}{"name": "a", "count": 1}); err != nil {
+		t.Fatalf("InsertRecordsUsingSchema: %v", err)
+	}
+
+	var insert string
+	for _, query := range conn.queries {
+		if strings.HasPrefix(query, "INSERT") {
+			insert = query
+		}
+	}
+	if !strings.Contains(insert, "($1, $2)") {
+		t.Errorf("INSERT = %q, want $n placeholders for the pgx driver", insert)
+	}
+}
@@ -0,0 +1,117 @@
+package dbtransposer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestTableQuotingIsIdenticalAcrossInsertPaths asserts the per-record path,
+// the batched path, and the dry-run script all render a schema-qualified
+// table name in the one segment-quoted style.
+func TestTableQuotingIsIdenticalAcrossInsertPaths(t *testing.T) {
+	const wantTable = `INSERT INTO "public"."loads"`
+	record := map[string]interface{}{"name": "a"}
+
+	// Per-record path
+	conn := &stubConn{}
+	db := openStubDB(t, conn)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("beginning stub transaction: %v", err)
+	}
+	defer tx.Rollback()
+	mp := &TransposerFunctions{Logger: zap.NewNop(), TemplateColumns: []string{"name"}}
+	if err := mp.InsertRecordsUsingSchema(tx, "public.loads", record); err != nil {
+		t.Fatalf("InsertRecordsUsingSchema: %v", err)
+	}
+
+	// Batched path
+	if err := mp.InsertRecordsBatch(tx, "public.loads", []map[string]interface{}{record}); err != nil {
+		t.Fatalf("InsertRecordsBatch: %v", err)
+	}
+
+	inserts := 0
+	for _, query := range conn.queries {
+		if !strings.HasPrefix(query, "INSERT") {
+			continue
+		}
+		inserts++
+		if !strings.HasPrefix(query, wantTable) {
+			t.Errorf("INSERT = %q, want it to start with %q", query, wantTable)
+		}
+	}
+	if inserts != 2 {
+		t.Fatalf("executed %d INSERTs, want 2 (per-record and batched); queries = %q", inserts, conn.queries)
+	}
+
+	// Dry-run script path
+	scriptPath := filepath.Join(t.TempDir(), "dry-run.sql")
+	writer, err := NewSQLScriptWriter(scriptPath, []string{"name"}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewSQLScriptWriter: %v", err)
+	}
+	if err := writer.WriteInsert("public.loads", []string{`"name"`}, [][]interface{}{{"a"}}, false); err != nil {
+		t.Fatalf("WriteInsert: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("closing script: %v", err)
+	}
+	script, err := os.ReadFile(scriptPath)
+	if err != nil {
+		t.Fatalf("reading script: %v", err)
+	}
+	if !strings.Contains(string(script), wantTable) {
+		t.Errorf("script = %q, want it to contain %q", script, wantTable)
+	}
+}
+
+// TestTombstoneAndDDLUseQuotedTableName covers the non-INSERT statement
+// builders: the tombstone DELETE and CREATE TABLE.
+func TestTombstoneAndDDLUseQuotedTableName(t *testing.T) {
+	conn := &stubConn{}
+	db := openStubDB(t, conn)
+	mp := &TransposerFunctions{
+		Logger:           zap.NewNop(),
+		TemplateColumns:  []string{"name"},
+		PKColumn:         "name",
+		SoftDeleteColumn: "deleted",
+		SoftDeleteValue:  "true",
+	}
+
+	if err := mp.CreateTableFromColumns(db, "public.loads", []string{"name"}); err != nil {
+		t.Fatalf("CreateTableFromColumns: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("beginning stub transaction: %v", err)
+	}
+	defer tx.Rollback()
+	tombstone := map[string]interface{}{"name": "a", "deleted": "true"}
+	if err := mp.InsertRecordsUsingSchema(tx, "public.loads", tombstone); err != nil {
+		t.Fatalf("InsertRecordsUsingSchema(tombstone): %v", err)
+	}
+
+	var sawCreate, sawDelete bool
+	for _, query := range conn.queries {
+		if strings.HasPrefix(query, "CREATE TABLE") {
+			sawCreate = true
+			if !strings.Contains(query, `"public"."loads"`) {
+				t.Errorf("CREATE TABLE = %q, want the quoted table name", query)
+			}
+		}
+		if strings.HasPrefix(query, "DELETE FROM") {
+			sawDelete = true
+			if !strings.HasPrefix(query, `DELETE FROM "public"."loads"`) {
+				t.Errorf("DELETE = %q, want the quoted table name", query)
+			}
+		}
+	}
+	if !sawCreate || !sawDelete {
+		t.Fatalf("missing statements (create=%v delete=%v); queries = %q", sawCreate, sawDelete, conn.queries)
+	}
+}
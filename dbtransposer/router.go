@@ -0,0 +1,89 @@
+package dbtransposer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TableRouter decides which table a record belongs in from its field values,
+// letting one file feed several tables (e.g. pending records to a staging table,
+// complete ones to the final table). Returning an empty string keeps the table
+// the run was invoked with.
+type TableRouter func(record map[string]interface{}) string
+
+// RouteRule is one routing decision: records whose Field's rendered value
+// matches Value under Operator go to Table. Supported operators: "eq", "ne",
+// "contains", and "prefix".
+type RouteRule struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+	Table    string `json:"table"`
+}
+
+// RouteConfig is the set of routing rules, evaluated in order with the first
+// match winning.
+type RouteConfig struct {
+	Rules []RouteRule `json:"rules"`
+}
+
+// LoadRouteConfig reads a RouteConfig from a JSON file.
+func LoadRouteConfig(path string) (RouteConfig, error) {
+	var cfg RouteConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read route config %q: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse route config %q: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// BuildTableRouter compiles a RouteConfig into a TableRouter. Rules are
+// evaluated in order and the first match wins; records matching no rule go to
+// defaultTable. An invalid rule (missing field/table or unknown operator) is
+// reported up front instead of surfacing mid-run.
+func BuildTableRouter(cfg RouteConfig, defaultTable string) (TableRouter, error) {
+	for i, rule := range cfg.Rules {
+		if rule.Field == "" {
+			return nil, fmt.Errorf("route rule %d: field is required", i)
+		}
+		if rule.Table == "" {
+			return nil, fmt.Errorf("route rule %d: table is required", i)
+		}
+		switch rule.Operator {
+		case "eq", "ne", "contains", "prefix":
+		default:
+			return nil, fmt.Errorf("route rule %d: unknown operator %q (supported: eq, ne, contains, prefix)", i, rule.Operator)
+		}
+	}
+
+	rules := append([]RouteRule(nil), cfg.Rules...)
+	return func(record map[string]interface{}) string {
+		for _, rule := range rules {
+			value, ok := record[rule.Field]
+			if !ok {
+				continue
+			}
+			rendered := fmt.Sprintf("%v", value)
+			matched := false
+			switch rule.Operator {
+			case "eq":
+				matched = rendered == rule.Value
+			case "ne":
+				matched = rendered != rule.Value
+			case "contains":
+				matched = strings.Contains(rendered, rule.Value)
+			case "prefix":
+				matched = strings.HasPrefix(rendered, rule.Value)
+			}
+			if matched {
+				return rule.Table
+			}
+		}
+		return defaultTable
+	}, nil
+}
@@ -0,0 +1,82 @@
+package dbtransposer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Built-in column transformers for the common data-quality problems source feeds
+// carry: stray whitespace, inconsistent casing, mixed date formats, and numbers
+// delivered as strings. Register them per column with RegisterTransformer; they
+// run before normalization and type coercion, so a transformer can hand a clean
+// value to the rest of the pipeline. Nil values pass through untouched.
+
+// TrimSpaceTransformer strips leading and trailing whitespace from string values.
+// Non-string values pass through unchanged.
+func TrimSpaceTransformer(value interface{}) (interface{}, error) {
+	if s, ok := value.(string); ok {
+		return strings.TrimSpace(s), nil
+	}
+	return value, nil
+}
+
+// ToUpperCaseTransformer upper-cases string values. Non-string values pass
+// through unchanged.
+func ToUpperCaseTransformer(value interface{}) (interface{}, error) {
+	if s, ok := value.(string); ok {
+		return strings.ToUpper(s), nil
+	}
+	return value, nil
+}
+
+// ToLowerCaseTransformer lower-cases string values. Non-string values pass
+// through unchanged.
+func ToLowerCaseTransformer(value interface{}) (interface{}, error) {
+	if s, ok := value.(string); ok {
+		return strings.ToLower(s), nil
+	}
+	return value, nil
+}
+
+// ParseISO8601Transformer parses string values as ISO 8601 timestamps (RFC 3339,
+// falling back to a bare date) into time.Time. Values that are already time.Time
+// or nil pass through; anything else that fails to parse is an error.
+func ParseISO8601Transformer(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, time.Time:
+		return v, nil
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if parsed, err := time.Parse(time.RFC3339, trimmed); err == nil {
+			return parsed, nil
+		}
+		if parsed, err := time.Parse("2006-01-02", trimmed); err == nil {
+			return parsed, nil
+		}
+		return nil, fmt.Errorf("not a valid ISO 8601 timestamp: %q", v)
+	default:
+		return nil, fmt.Errorf("cannot parse %T as a timestamp", value)
+	}
+}
+
+// ParseIntTransformer parses string and float values into int64. Values that are
+// already integers or nil pass through; anything else that fails to parse is an
+// error.
+func ParseIntTransformer(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, int, int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	case string:
+		parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("not a valid integer: %w", err)
+		}
+		return parsed, nil
+	default:
+		return nil, fmt.Errorf("cannot parse %T as an integer", value)
+	}
+}
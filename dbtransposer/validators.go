@@ -0,0 +1,168 @@
+package dbtransposer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValidationRule checks one column value before it is added to an INSERT. Rules
+// are attached per column via TransposerFunctions.ValidationRules; every failure
+// for a record is collected into a RecordValidationError so operators see all of
+// a record's problems at once instead of one DB error at a time.
+type ValidationRule interface {
+	Validate(column string, value interface{}) error
+}
+
+// FieldError describes a single column value that failed validation.
+type FieldError struct {
+	Column string
+	Value  interface{}
+	Err    error
+}
+
+// RecordValidationError aggregates every validation failure for one record. The
+// record is skipped (counted as an error) rather than sent to the database.
+type RecordValidationError struct {
+	Violations []FieldError
+}
+
+func (e *RecordValidationError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, fmt.Sprintf("%s (%v): %v", v.Column, v.Value, v.Err))
+	}
+	return fmt.Sprintf("record failed validation on %d field(s): %s", len(e.Violations), strings.Join(parts, "; "))
+}
+
+// NotNullRule rejects nil values.
+type NotNullRule struct{}
+
+func (NotNullRule) Validate(column string, value interface{}) error {
+	if value == nil {
+		return fmt.Errorf("value must not be null")
+	}
+	return nil
+}
+
+// MaxLengthRule rejects values whose string rendering exceeds N characters.
+type MaxLengthRule struct {
+	N int
+}
+
+func (r MaxLengthRule) Validate(column string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	if length := len(fmt.Sprintf("%v", value)); length > r.N {
+		return fmt.Errorf("length %d exceeds maximum %d", length, r.N)
+	}
+	return nil
+}
+
+// MinValueRule rejects numeric values below N. Non-numeric values are rejected
+// as well, since a bound implies the column is numeric.
+type MinValueRule struct {
+	N float64
+}
+
+func (r MinValueRule) Validate(column string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	number, err := toFloat(value)
+	if err != nil {
+		return err
+	}
+	if number < r.N {
+		return fmt.Errorf("value %v is below minimum %v", number, r.N)
+	}
+	return nil
+}
+
+// MaxValueRule rejects numeric values above N. Non-numeric values are rejected
+// as well, since a bound implies the column is numeric.
+type MaxValueRule struct {
+	N float64
+}
+
+func (r MaxValueRule) Validate(column string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	number, err := toFloat(value)
+	if err != nil {
+		return err
+	}
+	if number > r.N {
+		return fmt.Errorf("value %v exceeds maximum %v", number, r.N)
+	}
+	return nil
+}
+
+// RegexRule rejects string renderings that do not match Pattern. The pattern is
+// compiled lazily on first use and reused afterwards.
+type RegexRule struct {
+	Pattern string
+
+	compiled *regexp.Regexp
+}
+
+func (r *RegexRule) Validate(column string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	if r.compiled == nil {
+		compiled, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+		}
+		r.compiled = compiled
+	}
+	if rendered := fmt.Sprintf("%v", value); !r.compiled.MatchString(rendered) {
+		return fmt.Errorf("value %q does not match pattern %q", rendered, r.Pattern)
+	}
+	return nil
+}
+
+// OneOfRule rejects values outside the allowed set (compared by string rendering,
+// so "2" and 2 are treated as the same member).
+type OneOfRule struct {
+	Allowed []interface{}
+}
+
+func (r OneOfRule) Validate(column string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	rendered := fmt.Sprintf("%v", value)
+	for _, allowed := range r.Allowed {
+		if rendered == fmt.Sprintf("%v", allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("value %q is not one of the allowed values", rendered)
+}
+
+// toFloat converts the numeric types the decoders produce to float64 for bound checks.
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("value %q is not numeric", v)
+		}
+		return parsed, nil
+	default:
+		return 0, fmt.Errorf("value of type %T is not numeric", value)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build mysql
+
+package main
+
+// MySQL/MariaDB support is opt-in: build with -tags mysql after adding the
+// driver dependency ("go get github.com/go-sql-driver/mysql && go mod vendor").
+// The blank import registers the "mysql" driver that buildDSN resolves
+// DB_DRIVER mysql/mariadb to.
+import _ "github.com/go-sql-driver/mysql"
@@ -0,0 +1,9 @@
+//go:build oracle
+
+package main
+
+// Oracle support is opt-in: godror links against the Oracle client libraries,
+// so it is only compiled in when building with -tags oracle (run "go mod
+// vendor" first so its sources are vendored). The blank import registers the
+// "godror" driver that buildDSN resolves DB_DRIVER oracle/godror to.
+import _ "github.com/godror/godror"
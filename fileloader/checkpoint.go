@@ -0,0 +1,134 @@
+package fileloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// checkpointFileSuffix is appended to an input file's path to form its checkpoint
+// sidecar (e.g. data.xml -> data.xml.checkpoint.json).
+const checkpointFileSuffix = ".checkpoint.json"
+
+// Checkpoint records which records of an input file have already been committed, so
+// a crashed run can be resumed without re-inserting them. Committed record indexes
+// are kept as sorted, merged [first, last] ranges rather than a single high-water
+// mark because worker batches commit out of order. The source file's checksum is
+// stored alongside so a checkpoint is never applied to changed content.
+type Checkpoint struct {
+	Checksum        string   `json:"checksum"`
+	CommittedRanges [][2]int `json:"committedRanges"`
+}
+
+// Contains reports whether the record at the given index has already been committed.
+func (c *Checkpoint) Contains(index int) bool {
+	if c == nil {
+		return false
+	}
+	for _, r := range c.CommittedRanges {
+		if index >= r[0] && index <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// AddIndexes merges the given committed record indexes into the checkpoint's ranges,
+// coalescing adjacent and overlapping ranges so the sidecar stays small even for
+// multi-million-record files.
+func (c *Checkpoint) AddIndexes(indexes []int) {
+	if len(indexes) == 0 {
+		return
+	}
+	ranges := append([][2]int{}, c.CommittedRanges...)
+	for _, index := range indexes {
+		ranges = append(ranges, [2]int{index, index})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r[0] <= last[1]+1 {
+			if r[1] > last[1] {
+				last[1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	c.CommittedRanges = merged
+}
+
+// ReadCheckpoint loads the checkpoint sidecar for an input file, validating it
+// against the file's current checksum. A checkpoint whose checksum no longer
+// matches is stale — the file was replaced or modified since the interrupted run —
+// and is removed with a warning so the load starts from scratch.
+//
+// Parameters:
+//   - filePath: The input file whose checkpoint should be loaded.
+//   - checksum: The current digest of the input file's contents.
+//
+// Returns:
+//   - *Checkpoint: The valid checkpoint, or nil when none exists (or it was stale).
+//   - error: An error reading or parsing an existing sidecar.
+func (l *LoaderFunctions) ReadCheckpoint(filePath, checksum string) (*Checkpoint, error) {
+	checkpointPath := filePath + checkpointFileSuffix
+	payload, err := os.ReadFile(checkpointPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint file %s: %w", checkpointPath, err)
+	}
+
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(payload, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint file %s: %w", checkpointPath, err)
+	}
+
+	if checkpoint.Checksum != checksum {
+		l.Logger.Warn("Discarding stale checkpoint: source file checksum changed",
+			zap.String("checkpointPath", checkpointPath),
+			zap.String("checkpointChecksum", checkpoint.Checksum),
+			zap.String("fileChecksum", checksum),
+		)
+		if removeErr := os.Remove(checkpointPath); removeErr != nil && !os.IsNotExist(removeErr) {
+			return nil, fmt.Errorf("failed to remove stale checkpoint file %s: %w", checkpointPath, removeErr)
+		}
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
+
+// WriteCheckpoint persists the checkpoint sidecar atomically (write-temp-then-rename)
+// so a crash mid-write can never leave a truncated checkpoint behind.
+func (l *LoaderFunctions) WriteCheckpoint(filePath string, checkpoint *Checkpoint) error {
+	checkpointPath := filePath + checkpointFileSuffix
+	payload, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	tempPath := checkpointPath + ".tmp"
+	if err := os.WriteFile(tempPath, payload, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint file %s: %w", tempPath, err)
+	}
+	if err := os.Rename(tempPath, checkpointPath); err != nil {
+		return fmt.Errorf("failed to replace checkpoint file %s: %w", checkpointPath, err)
+	}
+	return nil
+}
+
+// RemoveCheckpoint deletes the checkpoint sidecar once a file has fully loaded, so
+// a later redelivery of the same path starts fresh.
+func (l *LoaderFunctions) RemoveCheckpoint(filePath string) error {
+	checkpointPath := filePath + checkpointFileSuffix
+	if err := os.Remove(checkpointPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove checkpoint file %s: %w", checkpointPath, err)
+	}
+	return nil
+}
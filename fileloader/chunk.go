@@ -0,0 +1,101 @@
+package fileloader
+
+import (
+	"data-ingestor/util"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChunkManifestEntry describes one part of a chunked export, so downstream
+// consumers can verify each file before loading it.
+type ChunkManifestEntry struct {
+	File     string `json:"file"`
+	Rows     int    `json:"rows"`
+	Checksum string `json:"checksum"` // sha256 hex digest of the part
+}
+
+// ExportChunked splits an export into numbered part files of at most
+// maxRecordsPerFile records each (export_0001.csv, export_0002.csv, ...), for
+// endpoints that reject large files. The format comes from the output path's
+// extension (.csv, .json, .ndjson, or .xlsx) and each part repeats the header,
+// since every part goes through the regular exporter. When manifestPath is
+// non-empty, a JSON manifest listing each part with its row count and sha256
+// checksum is written alongside the parts.
+//
+// Parameters:
+// - records: The flattened records to export.
+// - outputPath: The path pattern the part numbers are inserted into.
+// - maxRecordsPerFile: Records per part; <= 0 writes a single part.
+// - manifestPath: Optional path for the part manifest; empty disables it.
+//
+// Returns:
+// - The list of part files written, in order.
+// - An error if any part or the manifest fails to write.
+func (l *LoaderFunctions) ExportChunked(records []map[string]interface{}, outputPath string, maxRecordsPerFile int, manifestPath string) ([]string, error) {
+	if maxRecordsPerFile <= 0 {
+		maxRecordsPerFile = len(records)
+		if maxRecordsPerFile == 0 {
+			maxRecordsPerFile = 1
+		}
+	}
+
+	ext := filepath.Ext(outputPath)
+	base := strings.TrimSuffix(outputPath, ext)
+
+	var files []string
+	var manifest []ChunkManifestEntry
+	for start := 0; start < len(records) || start == 0; start += maxRecordsPerFile {
+		end := start + maxRecordsPerFile
+		if end > len(records) {
+			end = len(records)
+		}
+		chunk := records[start:end]
+		partPath := fmt.Sprintf("%s_%04d%s", base, len(files)+1, ext)
+
+		var err error
+		switch strings.ToLower(ext) {
+		case ".csv":
+			err = l.ExportToCSV(chunk, partPath)
+		case ".json", ".ndjson":
+			err = l.ExportToJSON(chunk, partPath)
+		case ".xlsx":
+			_, err = l.ExportToExcel(chunk, partPath)
+		default:
+			return nil, fmt.Errorf("cannot infer export format from %q (supported: .csv, .json, .ndjson, .xlsx)", outputPath)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write export part %s: %w", partPath, err)
+		}
+		files = append(files, partPath)
+
+		if manifestPath != "" {
+			checksum, sumErr := util.ComputeFileChecksum(partPath, "sha256")
+			if sumErr != nil {
+				return nil, fmt.Errorf("failed to checksum export part %s: %w", partPath, sumErr)
+			}
+			manifest = append(manifest, ChunkManifestEntry{File: partPath, Rows: len(chunk), Checksum: checksum})
+		}
+	}
+
+	if manifestPath != "" {
+		payload, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode export manifest: %w", err)
+		}
+		if err := os.WriteFile(manifestPath, append(payload, '\n'), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write export manifest %s: %w", manifestPath, err)
+		}
+	}
+
+	l.Logger.Info("Chunked export complete",
+		zap.String("outputPath", outputPath),
+		zap.Int("records", len(records)),
+		zap.Int("parts", len(files)),
+		zap.String("manifest", manifestPath),
+	)
+	return files, nil
+}
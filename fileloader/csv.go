@@ -0,0 +1,109 @@
+package fileloader
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVOptions customizes CSV export formatting for downstream consumers that need
+// something other than comma-delimited, LF-terminated output. The zero value
+// reproduces the historical defaults.
+type CSVOptions struct {
+	// Delimiter is the field separator; zero means ','.
+	Delimiter rune
+	// UseCRLF terminates lines with \r\n instead of \n.
+	UseCRLF bool
+	// NullToken renders nil and missing values (e.g. `\N` for PostgreSQL COPY
+	// consumers); empty keeps the historical empty-string rendering.
+	NullToken string
+	// AlwaysQuote wraps every field in double quotes, not just fields that need it.
+	AlwaysQuote bool
+	// OmitHeader skips the header row.
+	OmitHeader bool
+	// TimeLayout formats time.Time values; empty means time.RFC3339.
+	TimeLayout string
+}
+
+// formatCSVValue renders one value type-aware: floats keep full precision instead
+// of fmt's %v rounding, times use the configured layout, and everything else falls
+// back to %v. nil is handled by the caller via NullToken.
+func (o CSVOptions) formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		layout := o.TimeLayout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return v.Format(layout)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// csvRowWriter writes CSV rows honoring CSVOptions. The standard csv.Writer covers
+// the delimiter and CRLF options; AlwaysQuote is not something it supports, so that
+// mode writes quoted fields through a bufio.Writer directly.
+type csvRowWriter struct {
+	std  *csv.Writer
+	raw  *bufio.Writer
+	opts CSVOptions
+}
+
+// newCSVRowWriter builds a row writer for the given options on top of w.
+func newCSVRowWriter(w io.Writer, opts CSVOptions) *csvRowWriter {
+	if opts.AlwaysQuote {
+		return &csvRowWriter{raw: bufio.NewWriter(w), opts: opts}
+	}
+	std := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		std.Comma = opts.Delimiter
+	}
+	std.UseCRLF = opts.UseCRLF
+	return &csvRowWriter{std: std, opts: opts}
+}
+
+// Write emits one row.
+func (cw *csvRowWriter) Write(fields []string) error {
+	if cw.std != nil {
+		return cw.std.Write(fields)
+	}
+
+	delimiter := cw.opts.Delimiter
+	if delimiter == 0 {
+		delimiter = ','
+	}
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+	}
+	line := strings.Join(quoted, string(delimiter))
+	if cw.opts.UseCRLF {
+		line += "\r\n"
+	} else {
+		line += "\n"
+	}
+	_, err := cw.raw.WriteString(line)
+	return err
+}
+
+// Flush drains buffered rows and reports any deferred write error.
+func (cw *csvRowWriter) Flush() error {
+	if cw.std != nil {
+		cw.std.Flush()
+		return cw.std.Error()
+	}
+	return cw.raw.Flush()
+}
@@ -0,0 +1,73 @@
+package fileloader
+
+import (
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// drainStream collects everything StreamDecodeFileWithSchema emits for the file.
+func drainStream(t *testing.T, l *LoaderFunctions, path, modelName string, columns []string) []map[string]interface{} {
+	t.Helper()
+	recordChan := make(chan map[string]interface{}, 100)
+	var streamed []map[string]interface{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for record := range recordChan {
+			streamed = append(streamed, record)
+		}
+	}()
+	if err := l.StreamDecodeFileWithSchema(path, recordChan, modelName, columns); err != nil {
+		t.Fatalf("StreamDecodeFileWithSchema: %v", err)
+	}
+	close(recordChan)
+	<-done
+	return streamed
+}
+
+func TestDecodeFileWithSchemaMatchesStreamingJSON(t *testing.T) {
+	path := writeTempFile(t, "load.json", []byte(`{"Records": [
+		{"user": "alice", "loadNumber": "1"},
+		{"user": "bob", "loadNumber": "2"},
+		{"user": "carol", "loadNumber": "3"}
+	]}`))
+	columns := []string{"user", "loadNumber"}
+
+	l := &LoaderFunctions{Logger: zap.NewNop()}
+	decoded, err := l.DecodeFileWithSchema(path, "Record", columns)
+	if err != nil {
+		t.Fatalf("DecodeFileWithSchema: %v", err)
+	}
+	streamed := drainStream(t, &LoaderFunctions{Logger: zap.NewNop()}, path, "Record", columns)
+
+	if len(decoded) != 3 {
+		t.Fatalf("decoded %d records, want 3", len(decoded))
+	}
+	if !reflect.DeepEqual(decoded, streamed) {
+		t.Errorf("synchronous decode = %v, streaming = %v; want identical records in stream order", decoded, streamed)
+	}
+}
+
+func TestDecodeFileWithSchemaMatchesStreamingXML(t *testing.T) {
+	path := writeTempFile(t, "load.xml", []byte(`<Records>
+		<Record><user>alice</user><loadNumber>1</loadNumber></Record>
+		<Record><user>bob</user><loadNumber>2</loadNumber></Record>
+	</Records>`))
+	columns := []string{"user", "loadNumber"}
+
+	l := &LoaderFunctions{Logger: zap.NewNop()}
+	decoded, err := l.DecodeFileWithSchema(path, "Record", columns)
+	if err != nil {
+		t.Fatalf("DecodeFileWithSchema: %v", err)
+	}
+	streamed := drainStream(t, &LoaderFunctions{Logger: zap.NewNop()}, path, "Record", columns)
+
+	if len(decoded) != 2 {
+		t.Fatalf("decoded %d records, want 2", len(decoded))
+	}
+	if !reflect.DeepEqual(decoded, streamed) {
+		t.Errorf("synchronous decode = %v, streaming = %v; want identical records in stream order", decoded, streamed)
+	}
+}
@@ -0,0 +1,46 @@
+package fileloader
+
+import (
+	"crypto/sha256"
+	"data-ingestor/util"
+	"encoding/hex"
+	"fmt"
+	"go.uber.org/zap"
+	"sort"
+)
+
+// DeduplicatingChannel wraps a record channel and drops records whose key
+// columns match a record already seen, so duplicates produced by the upstream
+// exporter do not become duplicate rows. Duplicate detection hashes the values
+// of keyColumns (sorted alphabetically) with SHA-256 and remembers the hashes in
+// an LRU cache of cacheSize entries, keeping memory bounded on large files.
+// Dropped records are logged at DEBUG and counted as skipped.
+func (l *LoaderFunctions) DeduplicatingChannel(in <-chan map[string]interface{}, keyColumns []string, cacheSize int) <-chan map[string]interface{} {
+	sortedColumns := append([]string(nil), keyColumns...)
+	sort.Strings(sortedColumns)
+	cache := util.NewLRUCache(cacheSize)
+
+	out := make(chan map[string]interface{}, cap(in))
+	go func() {
+		defer close(out)
+		for record := range in {
+			hash := sha256.New()
+			for _, column := range sortedColumns {
+				fmt.Fprintf(hash, "%v", record[column])
+				hash.Write([]byte{0})
+			}
+			key := hex.EncodeToString(hash.Sum(nil))
+			if cache.Add(key) {
+				l.Logger.Debug("Dropping duplicate record",
+					zap.Strings("keyColumns", sortedColumns),
+					zap.Any("record", record))
+				if l.Counter != nil {
+					l.Counter.IncrementSkipped(1)
+				}
+				continue
+			}
+			out <- record
+		}
+	}()
+	return out
+}
@@ -0,0 +1,85 @@
+package fileloader
+
+import (
+	"fmt"
+	"github.com/xuri/excelize/v2"
+)
+
+// ExcelExportOptions customizes Excel export behavior. The zero value reproduces
+// the historical single-sheet, unstyled output (up to the format's row limit).
+type ExcelExportOptions struct {
+	// MaxRowsPerSheet is the number of data rows written to a sheet before the
+	// export rolls over to a new one (Sheet2, Sheet3, ...), repeating the header.
+	// Zero means just below excelize's row limit, leaving room for the header.
+	MaxRowsPerSheet int
+
+	// StyleHeader renders the header row bold on a light fill.
+	StyleHeader bool
+	// FreezeHeader freezes the top row so headers stay visible while scrolling.
+	FreezeHeader bool
+	// AutoFitColumns sizes each column to its longest rendered value.
+	AutoFitColumns bool
+	// MaxColumnWidth caps auto-fit widths, in characters; zero means 60.
+	MaxColumnWidth float64
+	// AutoFilter adds a filter over the data range of each sheet.
+	AutoFilter bool
+
+	// Append adds the records below the existing rows of the workbook at the
+	// output path instead of replacing it, verifying the existing header matches
+	// the records' columns. A missing workbook falls back to a fresh export.
+	Append bool
+}
+
+// rowsPerSheet resolves the effective data-row capacity of one sheet.
+func (o ExcelExportOptions) rowsPerSheet() int {
+	if o.MaxRowsPerSheet > 0 {
+		return o.MaxRowsPerSheet
+	}
+	// One row is reserved for the header on every sheet
+	return excelize.TotalRows - 1
+}
+
+// headerStyleID builds the bold/filled header style when StyleHeader is set;
+// zero means no style is applied.
+func (o ExcelExportOptions) headerStyleID(f *excelize.File) (int, error) {
+	if !o.StyleHeader {
+		return 0, nil
+	}
+	return f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#DDEBF7"}, Pattern: 1},
+	})
+}
+
+// columnWidths measures the longest rendered value per column (header included)
+// and returns capped widths in characters, or nil when auto-fit is disabled.
+func (o ExcelExportOptions) columnWidths(headers []string, records []map[string]interface{}) []float64 {
+	if !o.AutoFitColumns {
+		return nil
+	}
+	maxWidth := o.MaxColumnWidth
+	if maxWidth <= 0 {
+		maxWidth = 60
+	}
+	widths := make([]float64, len(headers))
+	for i, header := range headers {
+		widths[i] = float64(len(header))
+	}
+	for _, record := range records {
+		for i, header := range headers {
+			if value, exists := record[header]; exists && value != nil {
+				if w := float64(len(fmt.Sprintf("%v", value))); w > widths[i] {
+					widths[i] = w
+				}
+			}
+		}
+	}
+	// Pad for cell borders and cap runaway columns
+	for i := range widths {
+		widths[i] += 2
+		if widths[i] > maxWidth {
+			widths[i] = maxWidth
+		}
+	}
+	return widths
+}
@@ -0,0 +1,66 @@
+package fileloader
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestExtrasColumnCollectsUnmappedKeys(t *testing.T) {
+	l := &LoaderFunctions{Logger: zap.NewNop(), ExtrasColumn: "extras"}
+	columns := []string{"user", "loadNumber"}
+
+	record := map[string]interface{}{
+		"user":       "alice",
+		"loadNumber": "1",
+		"legacyCode": "X9",
+		"sourceTags": []interface{}{"a", "b"},
+	}
+	_, base := l.ParseAndFlattenJSONElement(record, columns)
+
+	// Mapped fields stay in their own columns
+	if base["user"] != "alice" || base["loadNumber"] != "1" {
+		t.Errorf("mapped fields = %v, want user and loadNumber routed to their columns", base)
+	}
+	if _, present := base["legacyCode"]; present {
+		t.Error("unmapped key legacyCode must not appear as its own column")
+	}
+
+	// Unmapped keys — including the whole primitive array — land in extras as JSON
+	encoded, ok := base["extras"].(string)
+	if !ok {
+		t.Fatalf("extras column = %v (%T), want a JSON string", base["extras"], base["extras"])
+	}
+	var extras map[string]interface{}
+	if err := json.Unmarshal([]byte(encoded), &extras); err != nil {
+		t.Fatalf("extras column is not valid JSON: %v", err)
+	}
+	want := map[string]interface{}{
+		"legacyCode": "X9",
+		"sourceTags": []interface{}{"a", "b"},
+	}
+	if !reflect.DeepEqual(extras, want) {
+		t.Errorf("extras = %v, want %v", extras, want)
+	}
+}
+
+func TestExtrasColumnAbsentWhenNothingUnmapped(t *testing.T) {
+	l := &LoaderFunctions{Logger: zap.NewNop(), ExtrasColumn: "extras"}
+
+	_, base := l.ParseAndFlattenJSONElement(map[string]interface{}{"user": "alice"}, []string{"user"})
+	if _, present := base["extras"]; present {
+		t.Errorf("record = %v, want no extras column when every key is mapped", base)
+	}
+}
+
+func TestUnmappedKeysDroppedWithoutExtrasColumn(t *testing.T) {
+	l := &LoaderFunctions{Logger: zap.NewNop()}
+
+	_, base := l.ParseAndFlattenJSONElement(
+		map[string]interface{}{"user": "alice", "legacyCode": "X9"}, []string{"user"})
+	if want := map[string]interface{}{"user": "alice"}; !reflect.DeepEqual(base, want) {
+		t.Errorf("record = %v, want unmapped keys dropped when no extras column is configured", base)
+	}
+}
@@ -0,0 +1,178 @@
+package fileloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+)
+
+// InferredColumn describes one column discovered while sampling an input file.
+type InferredColumn struct {
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`      // "string", "int64", "float64", or "bool"
+	NullRate  float64 `json:"nullRate"`  // Fraction of sampled records without a value
+	FromArray bool    `json:"fromArray"` // True when the key expands a record into multiple rows
+}
+
+// InferSchemaFromFile streams the first sampleSize records of a file and derives the union
+// of flattened keys with observed types and null rates. The result is deterministic (keys
+// sorted) so repeated runs produce diffable output.
+//
+// Parameters:
+// - filePath: The path to the file to sample.
+// - sampleSize: The maximum number of records to inspect.
+//
+// Returns:
+// - A sorted slice of inferred columns.
+// - An error if the file cannot be parsed.
+func (l *LoaderFunctions) InferSchemaFromFile(filePath string, sampleSize int) ([]InferredColumn, error) {
+	// Log the start of the inference run
+	l.Logger.Info("Starting schema inference",
+		zap.String("filePath", filePath),
+		zap.Int("sampleSize", sampleSize),
+	)
+
+	// Track keys that originate from nested arrays during flattening
+	l.ArrayKeys = map[string]struct{}{}
+	defer func() { l.ArrayKeys = nil }()
+
+	// Collect sample records with an empty column list so every key is accepted
+	records, err := l.collectSampleRecords(filePath, nil, sampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample input file: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no records found in %s", filePath)
+	}
+
+	// Union the observed keys with occurrence counts and inferred types
+	occurrences := map[string]int{}
+	types := map[string]string{}
+	for _, record := range records {
+		for key, value := range record {
+			occurrences[key]++
+			types[key] = mergeInferredType(types[key], inferValueType(value))
+		}
+	}
+
+	// Build the deterministic, sorted column list
+	keys := make([]string, 0, len(occurrences))
+	for key := range occurrences {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	inferred := make([]InferredColumn, 0, len(keys))
+	for _, key := range keys {
+		_, fromArray := l.ArrayKeys[key]
+		inferred = append(inferred, InferredColumn{
+			Name:      key,
+			Type:      types[key],
+			NullRate:  1 - float64(occurrences[key])/float64(len(records)),
+			FromArray: fromArray,
+		})
+	}
+
+	// Log the inference summary
+	l.Logger.Info("Finished schema inference",
+		zap.String("filePath", filePath),
+		zap.Int("recordsSampled", len(records)),
+		zap.Int("columnsInferred", len(inferred)),
+	)
+	return inferred, nil
+}
+
+// SampleRecords returns the first sampleSize flattened records of a file with
+// every key accepted, for callers that need the raw sample rather than the
+// inferred column summary (e.g. type-mapping inference in dbtransposer).
+func (l *LoaderFunctions) SampleRecords(filePath string, sampleSize int) ([]map[string]interface{}, error) {
+	return l.collectSampleRecords(filePath, nil, sampleSize)
+}
+
+// WriteInferredSchema writes the inferred columns either as an Excel template compatible
+// with ExtractSQLDataFromExcel (when outputPath ends in .xlsx) or as a JSON mapping file.
+func (l *LoaderFunctions) WriteInferredSchema(inferred []InferredColumn, outputPath string) error {
+	if strings.HasSuffix(outputPath, ".xlsx") {
+		return l.writeInferredExcelTemplate(inferred, outputPath)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create schema file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(inferred); err != nil {
+		return fmt.Errorf("failed to encode inferred schema: %w", err)
+	}
+	fmt.Printf("Successfully wrote inferred schema: %s\n", outputPath)
+	return nil
+}
+
+// writeInferredExcelTemplate writes the column names into row 3 of Sheet1 so the result
+// can be consumed directly by ExtractSQLDataFromExcel with the standard "A3:K3"-style range.
+func (l *LoaderFunctions) writeInferredExcelTemplate(inferred []InferredColumn, outputPath string) error {
+	f := excelize.NewFile()
+	sheetName := "Sheet1"
+
+	for colIndex, col := range inferred {
+		cell, _ := excelize.CoordinatesToCellName(colIndex+1, 3)
+		f.SetCellValue(sheetName, cell, col.Name)
+		// Record the inferred type one row below for the operator's reference
+		typeCell, _ := excelize.CoordinatesToCellName(colIndex+1, 4)
+		f.SetCellValue(sheetName, typeCell, col.Type)
+	}
+
+	if err := f.SaveAs(outputPath); err != nil {
+		return fmt.Errorf("failed to save Excel template: %w", err)
+	}
+	fmt.Printf("Successfully wrote inferred Excel template: %s\n", outputPath)
+	return nil
+}
+
+// inferValueType classifies a single flattened value.
+func inferValueType(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return "bool"
+	case float64:
+		// JSON numbers decode as float64; treat whole numbers as int64
+		if v == float64(int64(v)) {
+			return "int64"
+		}
+		return "float64"
+	case string:
+		if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return "int64"
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return "float64"
+		}
+		if _, err := strconv.ParseBool(v); err == nil {
+			return "bool"
+		}
+		return "string"
+	default:
+		return "string"
+	}
+}
+
+// mergeInferredType widens the running type for a column as new values are observed.
+func mergeInferredType(current, observed string) string {
+	switch {
+	case current == "" || current == observed:
+		return observed
+	case (current == "int64" && observed == "float64") || (current == "float64" && observed == "int64"):
+		return "float64"
+	default:
+		return "string"
+	}
+}
@@ -1,7 +1,10 @@
 package fileloader
 
 import (
-	"encoding/csv"
+	"bufio"
+	"bytes"
+	"context"
+	"data-ingestor/util"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -9,9 +12,406 @@ import (
 	"go.uber.org/zap"
 	"io"
 	"os"
+	"sort"
 	"strings"
+	"time"
 )
 
+// SampleFileWithSchema parses only the first sampleSize records of a file and reports column coverage
+// without touching the database. It is intended as a pre-flight sanity check before a long-running load.
+//
+// Parameters:
+// - filePath: The path to the file to sample.
+// - modelName: The name of the model being processed (used for contextual logging).
+// - columns: A list of column names defining the structure to map the file's content.
+// - sampleSize: The maximum number of records to parse before stopping.
+//
+// Returns:
+// - An error if file detection or parsing fails.
+func (l *LoaderFunctions) SampleFileWithSchema(filePath, modelName string, columns []string, sampleSize int) error {
+	// Log the start of the sampling run
+	l.Logger.Info("Starting sampling run",
+		zap.String("filePath", filePath),
+		zap.String("modelName", modelName),
+		zap.Int("sampleSize", sampleSize),
+	)
+
+	// Track unmapped keys encountered while flattening the sampled records
+	l.UnmappedKeyCounts = map[string]int{}
+	defer func() { l.UnmappedKeyCounts = nil }()
+
+	// Collect up to sampleSize flattened records
+	records, err := l.collectSampleRecords(filePath, columns, sampleSize)
+	if err != nil {
+		l.Logger.Error("Failed to sample input file", zap.String("filePath", filePath), zap.Error(err))
+		return fmt.Errorf("failed to sample input file: %w", err)
+	}
+
+	// Compute per-column coverage: how many sampled records had a value for each column
+	coverage := make(map[string]int)
+	for _, record := range records {
+		for _, col := range columns {
+			if _, ok := record[col]; ok {
+				coverage[col]++
+			}
+		}
+	}
+
+	// Print the coverage table
+	fmt.Printf("Sampled %d record(s) from %s\n\n", len(records), filePath)
+	fmt.Printf("%-30s %s\n", "COLUMN", "RECORDS WITH VALUE")
+	for _, col := range columns {
+		fmt.Printf("%-30s %d/%d\n", col, coverage[col], len(records))
+	}
+
+	// Print every unmapped key encountered during flattening
+	if len(l.UnmappedKeyCounts) > 0 {
+		fmt.Printf("\n%-30s %s\n", "UNMAPPED KEY", "OCCURRENCES")
+		for key, count := range l.UnmappedKeyCounts {
+			fmt.Printf("%-30s %d\n", key, count)
+		}
+	}
+
+	// Log successful completion
+	l.Logger.Info("Finished sampling run",
+		zap.String("filePath", filePath),
+		zap.Int("recordsSampled", len(records)),
+		zap.Int("unmappedKeys", len(l.UnmappedKeyCounts)),
+	)
+	return nil
+}
+
+// ValidationReport summarizes a validation-only run over one input file.
+type ValidationReport struct {
+	InputFile       string         `json:"inputFile"`
+	ModelName       string         `json:"modelName"`
+	TotalRecords    int            `json:"totalRecords"`
+	ValidRecords    int            `json:"validRecords"`
+	RejectedRecords int            `json:"rejectedRecords"`
+	RejectionReasons map[string]int `json:"rejectionReasons"`
+	UnmappedKeys    map[string]int `json:"unmappedKeys"`
+}
+
+// ValidateFileWithSchema fully parses and flattens the file, applying column validation,
+// but performs zero inserts. It is intended as a pre-flight step for schedulers and CI:
+// the caller can gate on RejectedRecords > 0.
+//
+// Parameters:
+// - filePath: The path to the file to validate.
+// - modelName: The name of the model being processed (used for contextual logging).
+// - columns: A list of column names defining the structure to map the file's content.
+//
+// Returns:
+// - A ValidationReport with per-reason rejection counts and unmapped key occurrences.
+// - An error if the file cannot be parsed at all.
+func (l *LoaderFunctions) ValidateFileWithSchema(filePath, modelName string, columns []string) (*ValidationReport, error) {
+	// Log the start of the validation run
+	l.Logger.Info("Starting validation-only run",
+		zap.String("filePath", filePath),
+		zap.String("modelName", modelName),
+	)
+
+	// Track unmapped keys encountered while flattening
+	l.UnmappedKeyCounts = map[string]int{}
+	defer func() { l.UnmappedKeyCounts = nil }()
+
+	// Detect the file type and flatten the whole file into the no-op sink
+	fileType, err := l.detectFileType(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect file type: %w", err)
+	}
+
+	// Disable required-column rejection during flattening so rejected records are
+	// reported in the validation summary rather than silently dropped
+	required := l.RequiredColumns
+	l.RequiredColumns = nil
+	defer func() { l.RequiredColumns = required }()
+
+	var records []map[string]interface{}
+	switch fileType {
+	case "json":
+		records, err = l.FlattenJSONToMaps(filePath, columns)
+	case "xml":
+		records, err = l.FlattenXMLToMaps(filePath, columns)
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", fileType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to flatten input file: %w", err)
+	}
+
+	report := &ValidationReport{
+		InputFile:        filePath,
+		ModelName:        modelName,
+		TotalRecords:     len(records),
+		RejectionReasons: map[string]int{},
+		UnmappedKeys:     l.UnmappedKeyCounts,
+	}
+
+	// Apply record-level validation without inserting anything
+	for _, record := range records {
+		if len(record) == 0 {
+			report.RejectedRecords++
+			report.RejectionReasons["no mapped columns"]++
+			continue
+		}
+		if missing := missingRequiredColumns(record, required); len(missing) > 0 {
+			report.RejectedRecords++
+			for _, col := range missing {
+				report.RejectionReasons[fmt.Sprintf("missing required column: %s", col)]++
+			}
+			continue
+		}
+		report.ValidRecords++
+	}
+
+	// Log the validation summary
+	l.Logger.Info("Finished validation-only run",
+		zap.String("filePath", filePath),
+		zap.Int("totalRecords", report.TotalRecords),
+		zap.Int("validRecords", report.ValidRecords),
+		zap.Int("rejectedRecords", report.RejectedRecords),
+	)
+	return report, nil
+}
+
+// WriteValidationReport serializes the report as indented JSON to the given path,
+// or to stdout when the path is empty.
+func (l *LoaderFunctions) WriteValidationReport(report *ValidationReport, reportPath string) error {
+	out := os.Stdout
+	if reportPath != "" {
+		file, err := os.Create(reportPath)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to encode validation report: %w", err)
+	}
+	return nil
+}
+
+// missingRequiredColumns returns which of the given required columns are absent from the
+// flattened record.
+func missingRequiredColumns(record map[string]interface{}, required []string) []string {
+	var missing []string
+	for _, col := range required {
+		if _, ok := record[col]; !ok {
+			missing = append(missing, col)
+		}
+	}
+	return missing
+}
+
+// transformAndValidate runs the configured Transforms over a flattened record, in order,
+// then validates it against RequiredColumns. It returns the transformed record and false
+// when the record should be rejected, logging the failing transform by name.
+func (l *LoaderFunctions) transformAndValidate(record map[string]interface{}) (map[string]interface{}, bool) {
+	l.deadLetterOrdinal++
+	for _, transform := range l.Transforms {
+		transformed, err := transform.Fn(record)
+		if err != nil {
+			l.Logger.Warn("Rejecting record failed by transform",
+				zap.String("transform", transform.Name),
+				zap.Error(err),
+				zap.Any("record", record))
+			l.DeadLetter.Record(util.DeadLetterEntry{
+				RecordIndex: l.deadLetterOrdinal,
+				Reason:      util.DeadLetterReasonTransform,
+				Error:       fmt.Sprintf("transform %s: %v", transform.Name, err),
+				Record:      record,
+			})
+			return nil, false
+		}
+		record = transformed
+	}
+	if !l.checkRequiredColumns(record) {
+		missing := missingRequiredColumns(record, l.RequiredColumns)
+		l.DeadLetter.Record(util.DeadLetterEntry{
+			RecordIndex: l.deadLetterOrdinal,
+			Reason:      util.DeadLetterReasonValidation,
+			Error:       "missing required column(s): " + strings.Join(missing, ", "),
+			Record:      record,
+		})
+		return nil, false
+	}
+	return record, true
+}
+
+// checkRequiredColumns validates a flattened record against the configured RequiredColumns,
+// logging and rejecting (returning false for) records that are missing any of them.
+func (l *LoaderFunctions) checkRequiredColumns(record map[string]interface{}) bool {
+	if len(l.RequiredColumns) == 0 {
+		return true
+	}
+	if missing := missingRequiredColumns(record, l.RequiredColumns); len(missing) > 0 {
+		l.Logger.Warn("Rejecting record missing required column(s)",
+			zap.Strings("missingColumns", missing),
+			zap.Any("record", record))
+		return false
+	}
+	return true
+}
+
+// recordUnmappedKey counts a dropped key when unmapped-key tracking is enabled (sampling runs).
+func (l *LoaderFunctions) recordUnmappedKey(key string) {
+	if l.UnmappedKeyCounts != nil {
+		l.UnmappedKeyCounts[key]++
+	}
+}
+
+// addExtra stashes an unmapped key/value into the record's extras map, creating the
+// map on first use. Only called when ExtrasColumn is configured.
+func (l *LoaderFunctions) addExtra(record map[string]interface{}, key string, value interface{}) {
+	extras, ok := record[l.ExtrasColumn].(map[string]interface{})
+	if !ok {
+		extras = make(map[string]interface{})
+		record[l.ExtrasColumn] = extras
+	}
+	extras[key] = value
+	l.Logger.Debug("Preserving unmapped key in extras column",
+		zap.String("key", key),
+		zap.String("extrasColumn", l.ExtrasColumn))
+}
+
+// serializeExtras renders a record's accumulated extras map as a JSON string so the
+// value binds cleanly to a jsonb/text column. No-op when the extras column is unset
+// or the record carries no extras.
+func (l *LoaderFunctions) serializeExtras(record map[string]interface{}) {
+	if l.ExtrasColumn == "" {
+		return
+	}
+	extras, ok := record[l.ExtrasColumn].(map[string]interface{})
+	if !ok || len(extras) == 0 {
+		return
+	}
+	encoded, err := json.Marshal(extras)
+	if err != nil {
+		l.Logger.Warn("Failed to serialize extras column; dropping extras",
+			zap.String("extrasColumn", l.ExtrasColumn),
+			zap.Error(err))
+		delete(record, l.ExtrasColumn)
+		return
+	}
+	record[l.ExtrasColumn] = string(encoded)
+}
+
+// isPrimitiveArray reports whether every element of the array is a primitive
+// (no object elements), i.e. the array can be preserved whole in the extras column.
+func isPrimitiveArray(elements []interface{}) bool {
+	for _, element := range elements {
+		if _, ok := element.(map[string]interface{}); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// recordArrayKey marks a key as originating from a nested array when tracking is enabled
+// (schema inference runs).
+func (l *LoaderFunctions) recordArrayKey(key string) {
+	if l.ArrayKeys != nil {
+		l.ArrayKeys[key] = struct{}{}
+	}
+}
+
+// collectSampleRecords parses and flattens records from the file, stopping once sampleSize records
+// have been collected so large files do not need to be read in full.
+func (l *LoaderFunctions) collectSampleRecords(filePath string, columns []string, sampleSize int) ([]map[string]interface{}, error) {
+	// Detect the file type (JSON or XML)
+	fileType, err := l.detectFileType(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect file type: %w", err)
+	}
+
+	switch fileType {
+	case "json":
+		// JSON files are decoded as a whole; flatten and truncate to the sample size
+		records, err := l.FlattenJSONToMaps(filePath, columns)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > sampleSize {
+			records = records[:sampleSize]
+		}
+		return records, nil
+	case "xml":
+		// XML is token-streamed, so parsing can stop early once enough records are collected
+		file, err := l.openInputReader(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open XML file: %w", err)
+		}
+		defer file.Close()
+
+		decoder := xml.NewDecoder(file)
+		var records []map[string]interface{}
+		for len(records) < sampleSize {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read XML token: %w", err)
+			}
+
+			if se, ok := token.(xml.StartElement); ok && se.Name.Local == "Record" {
+				flattenedRecords, err := l.ParseAndFlattenXMLElementWithColumns(decoder, se, columns)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse <Record>: %w", err)
+				}
+				records = append(records, flattenedRecords...)
+			}
+		}
+		if len(records) > sampleSize {
+			records = records[:sampleSize]
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", fileType)
+	}
+}
+
+// DecodeFileWithSchema is the synchronous counterpart of StreamDecodeFileWithSchema
+// for unit tests, ad-hoc validation, and small files: it drains the streaming
+// decoder into a slice and returns every record at once. It applies the same
+// schema mapping, transforms, and validation as the streaming path, so the
+// returned slice matches what streaming would produce. Large files should use
+// the streaming API to keep memory flat.
+//
+// Parameters:
+// - filePath: The path to the file to decode.
+// - modelName: The name of the model to map the file content to.
+// - columns: A list of column names defining the structure to map the file's content.
+//
+// Returns:
+// - Every decoded record, in stream order.
+// - An error if streaming or file processing fails.
+func (l *LoaderFunctions) DecodeFileWithSchema(filePath, modelName string, columns []string) ([]map[string]interface{}, error) {
+	recordChan := make(chan map[string]interface{}, 100)
+
+	var records []map[string]interface{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for record := range recordChan {
+			records = append(records, record)
+		}
+	}()
+
+	err := l.StreamDecodeFileWithSchema(filePath, recordChan, modelName, columns)
+	close(recordChan)
+	<-done
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
 // StreamDecodeFileWithSchema streams the file content record-by-record, applying a schema for column mapping.
 //
 // Parameters:
@@ -23,6 +423,9 @@ import (
 // Returns:
 // - An error if streaming or file processing fails.
 func (l *LoaderFunctions) StreamDecodeFileWithSchema(filePath string, recordChan chan map[string]interface{}, modelName string, columns []string) error {
+	start := time.Now()
+	l.deadLetterOrdinal = -1
+
 	// Log the start of the streaming process
 	l.Logger.Info("Starting file streaming with schema",
 		zap.String("filePath", filePath),
@@ -30,6 +433,50 @@ func (l *LoaderFunctions) StreamDecodeFileWithSchema(filePath string, recordChan
 		zap.Strings("columns", columns),
 	)
 
+	// Give external integrations (virus scanners, readiness checks) a chance to
+	// veto the file before anything is opened
+	if l.PreLoadHook != nil {
+		if hookErr := l.PreLoadHook(context.Background(), filePath); hookErr != nil {
+			if l.Counter != nil {
+				l.Counter.IncrementErrors(1)
+			}
+			l.Logger.Error("Pre-load hook rejected file",
+				zap.String("filePath", filePath),
+				zap.Error(hookErr),
+			)
+			return fmt.Errorf("pre-load hook rejected file: %w", hookErr)
+		}
+	}
+
+	// Refuse oversized files before any decoding starts
+	if l.MaxFileSizeBytes > 0 {
+		info, statErr := os.Stat(filePath)
+		if statErr != nil {
+			return fmt.Errorf("failed to stat input file: %w", statErr)
+		}
+		if info.Size() > l.MaxFileSizeBytes {
+			sizeErr := &ErrFileTooLarge{Size: info.Size(), Max: l.MaxFileSizeBytes}
+			l.Logger.Error("Input file exceeds the size limit",
+				zap.String("filePath", filePath),
+				zap.Int64("sizeBytes", info.Size()),
+				zap.Int64("maxBytes", l.MaxFileSizeBytes),
+			)
+			return sizeErr
+		}
+	}
+
+	// Validate the file against an explicitly supplied checksum before any records
+	// are decoded, so corrupt or incomplete transfers are refused up front
+	if l.ExpectedChecksum != "" {
+		if err := l.ValidateFileChecksum(filePath, l.ExpectedChecksum, l.ChecksumAlgorithm); err != nil {
+			l.Logger.Error("File checksum validation failed",
+				zap.String("filePath", filePath),
+				zap.Error(err),
+			)
+			return fmt.Errorf("checksum validation failed: %w", err)
+		}
+	}
+
 	// Detect the file type (JSON or XML)
 	fileType, err := l.detectFileType(filePath)
 	if err != nil {
@@ -41,20 +488,49 @@ func (l *LoaderFunctions) StreamDecodeFileWithSchema(filePath string, recordChan
 		return fmt.Errorf("failed to detect file type: %w", err)
 	}
 
+	// When a post-load hook needs the record count, stream through a counting proxy
+	// channel so the total is known once the decoder finishes
+	outChan := recordChan
+	recordCount := 0
+	var proxyDone chan struct{}
+	if l.PostLoadHook != nil {
+		proxy := make(chan map[string]interface{}, cap(recordChan))
+		proxyDone = make(chan struct{})
+		go func() {
+			defer close(proxyDone)
+			for record := range proxy {
+				recordCount++
+				recordChan <- record
+			}
+		}()
+		outChan = proxy
+	}
+
 	// Process the file based on its type
+	var streamErr error
 	switch fileType {
 	case "json":
-		return l.StreamJSONFileWithSchema(filePath, recordChan, columns)
+		streamErr = l.StreamJSONFileWithSchema(filePath, outChan, columns)
 	case "xml":
-		return l.StreamXMLFileWithSchema(filePath, recordChan, modelName, columns)
+		streamErr = l.StreamXMLFileWithSchema(filePath, outChan, modelName, columns)
 	default:
 		// Log and return the error for unsupported file types
 		l.Logger.Error("Unsupported file type",
 			zap.String("filePath", filePath),
 			zap.String("fileType", fileType),
 		)
-		return fmt.Errorf("unsupported file type: %s", fileType)
+		streamErr = fmt.Errorf("unsupported file type: %s", fileType)
 	}
+
+	// Notify external integrations once every record has been sent; the hook
+	// observes the outcome but cannot change it
+	if l.PostLoadHook != nil {
+		close(outChan)
+		<-proxyDone
+		l.PostLoadHook(context.Background(), filePath, recordCount, time.Since(start), streamErr)
+	}
+
+	return streamErr
 }
 
 
@@ -73,12 +549,12 @@ func (l *LoaderFunctions) StreamJSONFileWithSchema(filePath string, recordChan c
 	l.Logger.Info("Starting JSON streaming for file with top-level key", zap.String("filePath", filePath))
 
 	// Open the JSON file
-	file, err := os.Open(filePath)
+	file, err := l.openInputReader(filePath)
 	if err != nil {
 		l.Logger.Error("Failed to open JSON file", zap.String("filePath", filePath), zap.Error(err))
 		return fmt.Errorf("failed to open JSON file: %w", err)
 	}
-	//defer file.Close() // Ensure file closure
+	defer file.Close()
 
 	l.Logger.Debug("Loaded allowed columns for validation", zap.Strings("columns", columns))
 
@@ -111,11 +587,19 @@ func (l *LoaderFunctions) StreamJSONFileWithSchema(filePath string, recordChan c
 
 		// If no nested rows, send the base record as-is
 		if len(nestedRows) == 0 {
+			baseRecord, ok := l.transformAndValidate(baseRecord)
+			if !ok {
+				continue
+			}
 			l.Logger.Debug("Streaming base record", zap.Any("record", baseRecord))
 			recordChan <- baseRecord
 		} else {
 			// Stream each row generated from nested elements
 			for _, row := range nestedRows {
+				row, ok := l.transformAndValidate(row)
+				if !ok {
+					continue
+				}
 				l.Logger.Debug("Streaming flattened row", zap.Any("row", row))
 				recordChan <- row
 			}
@@ -144,11 +628,12 @@ func (l *LoaderFunctions) StreamXMLFileWithSchema(filePath string, recordChan ch
 	l.Logger.Info("Starting XML streaming", zap.String("filePath", filePath), zap.String("modelName", modelName))
 
 	// Open the XML file
-	file, err := os.Open(filePath)
+	file, err := l.openInputReader(filePath)
 	if err != nil {
 		l.Logger.Error("Failed to open XML file", zap.String("filePath", filePath), zap.Error(err))
 		return fmt.Errorf("failed to open XML file: %w", err)
 	}
+	defer file.Close()
 
 	// Initialize the XML decoder
 	decoder := xml.NewDecoder(file)
@@ -185,6 +670,10 @@ func (l *LoaderFunctions) StreamXMLFileWithSchema(filePath string, recordChan ch
 
 			// Send each flattened record to the channel
 			for _, rec := range flattenedRecords {
+				rec, ok := l.transformAndValidate(rec)
+				if !ok {
+					continue
+				}
 				l.Logger.Debug("Sending record to channel", zap.Any("record", rec))
 				recordChan <- rec
 			}
@@ -196,13 +685,92 @@ func (l *LoaderFunctions) StreamXMLFileWithSchema(filePath string, recordChan ch
 	return nil
 }
 
+// StreamXMLMultiModel streams records from an XML file that interleaves several record
+// element types (e.g. <Record> and <Correction>), each with its own schema. Every
+// emitted record is tagged with the reserved ModelKey ("__model") set to the element
+// name so downstream insert logic can route it to the right table.
+//
+// Parameters:
+// - filePath: The path to the XML file to be streamed.
+// - recordChan: A channel to send the parsed and flattened records.
+// - modelColumns: A map of record element name to the column list for that model.
+//
+// Returns:
+// - An error if any issues occur during file processing or parsing.
+func (l *LoaderFunctions) StreamXMLMultiModel(filePath string, recordChan chan map[string]interface{}, modelColumns map[string][]string) error {
+	// Log the start of multi-model XML streaming
+	models := make([]string, 0, len(modelColumns))
+	for model := range modelColumns {
+		models = append(models, model)
+	}
+	l.Logger.Info("Starting multi-model XML streaming", zap.String("filePath", filePath), zap.Strings("models", models))
+
+	// Open the XML file
+	file, err := l.openInputReader(filePath)
+	if err != nil {
+		l.Logger.Error("Failed to open XML file", zap.String("filePath", filePath), zap.Error(err))
+		return fmt.Errorf("failed to open XML file: %w", err)
+	}
+	defer file.Close()
+
+	// Initialize the XML decoder
+	decoder := xml.NewDecoder(file)
+
+	for {
+		// Read the next XML token
+		token, err := decoder.Token()
+		if err == io.EOF {
+			l.Logger.Info("Reached EOF for XML file", zap.String("filePath", filePath))
+			break
+		}
+		if err != nil {
+			l.Logger.Error("Failed to read XML token", zap.String("filePath", filePath), zap.Error(err))
+			return fmt.Errorf("failed to read XML token: %w", err)
+		}
+
+		// Check for the start of any configured record element
+		se, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		columns, ok := modelColumns[se.Name.Local]
+		if !ok {
+			continue
+		}
+		l.Logger.Debug("Processing record element", zap.String("element", se.Name.Local))
+
+		// Parse and flatten the element against its model's columns
+		flattenedRecords, err := l.ParseAndFlattenXMLElementWithColumns(decoder, se, columns)
+		if err != nil {
+			l.Logger.Error("Failed to parse record element", zap.String("filePath", filePath), zap.String("element", se.Name.Local), zap.Error(err))
+			return fmt.Errorf("failed to parse <%s>: %w", se.Name.Local, err)
+		}
+
+		// Tag each record with its model and send it to the channel
+		for _, rec := range flattenedRecords {
+			rec, ok := l.transformAndValidate(rec)
+			if !ok {
+				continue
+			}
+			rec[ModelKey] = se.Name.Local
+			l.Logger.Debug("Sending record to channel", zap.String("model", se.Name.Local), zap.Any("record", rec))
+			recordChan <- rec
+		}
+	}
+
+	// Log successful completion of multi-model XML streaming
+	l.Logger.Info("Finished multi-model XML streaming", zap.String("filePath", filePath))
+	return nil
+}
+
 
 func (l *LoaderFunctions) FlattenXMLToMaps(filePath string, columns []string) ([]map[string]interface{}, error) {
 	// Open the XML file
-	file, err := os.Open(filePath)
+	file, err := l.openInputReader(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open XML file: %w", err)
 	}
+	defer file.Close()
 
 	decoder := xml.NewDecoder(file)
 	var records []map[string]interface{}
@@ -222,7 +790,13 @@ func (l *LoaderFunctions) FlattenXMLToMaps(filePath string, columns []string) ([
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse <Record>: %w", err)
 			}
-			records = append(records, flattenedRecords...)
+			for _, rec := range flattenedRecords {
+				rec, ok := l.transformAndValidate(rec)
+				if !ok {
+					continue
+				}
+				records = append(records, rec)
+			}
 		}
 	}
 	return records, nil
@@ -243,12 +817,12 @@ func (l *LoaderFunctions) FlattenJSONToMaps(filePath string, columns []string) (
 	l.Logger.Info("Starting JSON streaming for file with top-level key", zap.String("filePath", filePath))
 
 	// Open the JSON file
-	file, err := os.Open(filePath)
+	file, err := l.openInputReader(filePath)
 	if err != nil {
 		l.Logger.Error("Failed to open JSON file", zap.String("filePath", filePath), zap.Error(err))
 		return nil, fmt.Errorf("failed to open JSON file: %w", err)
 	}
-	//defer file.Close() // Ensure file closure
+	defer file.Close()
 
 	l.Logger.Debug("Loaded allowed columns for validation", zap.Strings("columns", columns))
 
@@ -281,11 +855,21 @@ func (l *LoaderFunctions) FlattenJSONToMaps(filePath string, columns []string) (
 		nestedRows, baseRecord := l.ParseAndFlattenJSONElement(recordMap, columns)
 		// If no nested rows, send the base record as-is
 		if len(nestedRows) == 0 {
+			baseRecord, ok := l.transformAndValidate(baseRecord)
+			if !ok {
+				continue
+			}
 			l.Logger.Debug("Loading base record", zap.Any("record", baseRecord))
 			rows = append(rows, baseRecord)
 		} else {
 			// Stream each row generated from nested elements
-			rows = append(rows, nestedRows...)
+			for _, row := range nestedRows {
+				row, ok := l.transformAndValidate(row)
+				if !ok {
+					continue
+				}
+				rows = append(rows, row)
+			}
 		}
 	}
 
@@ -295,74 +879,162 @@ func (l *LoaderFunctions) FlattenJSONToMaps(filePath string, columns []string) (
 }
 
 func (l *LoaderFunctions) ParseAndFlattenJSONElement(recordMap map[string]interface{}, columns []string) (nestedRows []map[string]interface{}, baseRecord map[string]interface{}) {
-	// Create a set for quick validation of allowed columns
+	// Create a set for quick validation of allowed columns.
+	// An empty column list means "accept all keys" (used by schema inference).
+	acceptAll := len(columns) == 0
 	columnSet := make(map[string]struct{})
 	for _, col := range columns {
 		columnSet[col] = struct{}{}
 	}
+	allowed := func(key string) bool {
+		if acceptAll {
+			return true
+		}
+		_, ok := columnSet[key]
+		return ok
+	}
 	l.Logger.Debug("Loaded allowed columns for validation", zap.Strings("columns", columns))
 
-	// Initialize baseRecord to avoid nil map issues
-	baseRecord = make(map[string]interface{})
+	// Recursively expand every nested array into the cross-product of rows, copying
+	// base fields down into each row
+	rows, hasArrays := l.flattenJSONRecordRows(recordMap, allowed)
 
-	// Separate base fields and process nested arrays
+	// Render accumulated extras as JSON strings so the column binds like any other
+	for _, row := range rows {
+		l.serializeExtras(row)
+	}
+
+	// Records with no arrays keep the original single-base-record shape
+	if !hasArrays {
+		if len(rows) == 0 {
+			return nil, make(map[string]interface{})
+		}
+		return nil, rows[0]
+	}
+	return rows, make(map[string]interface{})
+}
+
+// flattenJSONRecordRows recursively flattens a record map into fully-expanded rows.
+// Every array — at any depth, including arrays inside array elements and sibling
+// arrays — multiplies the row set, producing the cartesian product with base fields
+// copied into each row. The second return reports whether any array was expanded, so
+// callers can distinguish a single flattened row from a plain base record.
+func (l *LoaderFunctions) flattenJSONRecordRows(recordMap map[string]interface{}, allowed func(string) bool) ([]map[string]interface{}, bool) {
+	// Split the record into scalar/object base fields and the arrays to expand,
+	// keeping array expansion order deterministic
+	base := make(map[string]interface{})
+	var arrayKeys []string
 	for key, value := range recordMap {
-		// Validate the key against the allowed columns
-		//if _, allowed := columnSet[key]; !allowed {
-		//	l.Logger.Warn("Skipping unmapped key", zap.String("key", key))
-		//	continue
-		//}
-
-		switch v := value.(type) {
-		case []interface{}: // Handle arrays dynamically
-			for _, nested := range v {
-				if nestedMap, ok := nested.(map[string]interface{}); ok {
-					flattenedRow := make(map[string]interface{})
-					// Copy base fields to the new row
-					for baseKey, baseValue := range recordMap {
-						if baseKey != key { // Exclude the current array key
-							// Validate the baseKey
-							if _, allowed := columnSet[baseKey]; allowed {
-								flattenedRow[baseKey] = baseValue
-							} else {
-								l.Logger.Warn("Skipping unmapped base key", zap.String("baseKey", baseKey))
-							}
-						}
+		if elements, isArray := value.([]interface{}); isArray {
+			// Unmapped primitive arrays are preserved whole in the extras column
+			// rather than expanded into rows; object arrays still expand so their
+			// individual keys are validated
+			if !allowed(key) && l.ExtrasColumn != "" && isPrimitiveArray(elements) {
+				l.addExtra(base, key, value)
+				continue
+			}
+			arrayKeys = append(arrayKeys, key)
+			continue
+		}
+		if allowed(key) {
+			base[key] = value
+		} else if l.ExtrasColumn != "" {
+			l.addExtra(base, key, value)
+		} else {
+			l.Logger.Warn("Skipping unmapped key in base record", zap.String("key", key))
+			l.recordUnmappedKey(key)
+		}
+	}
+	sort.Strings(arrayKeys)
+
+	rows := []map[string]interface{}{base}
+	hasArrays := false
+	for _, key := range arrayKeys {
+		elements := recordMap[key].([]interface{})
+		if len(elements) == 0 {
+			continue
+		}
+
+		// Flatten each array element into its own set of rows; an element containing
+		// further arrays recursively expands into multiple rows itself
+		var elementRows []map[string]interface{}
+		for _, element := range elements {
+			if elementMap, ok := element.(map[string]interface{}); ok {
+				subRows, _ := l.flattenJSONRecordRows(elementMap, allowed)
+				for _, subRow := range subRows {
+					for subKey := range subRow {
+						l.recordArrayKey(subKey)
 					}
-					// Add nested fields to the row
-					for nestedKey, nestedValue := range nestedMap {
-						if _, allowed := columnSet[nestedKey]; allowed {
-							flattenedRow[nestedKey] = nestedValue
-						} else {
-							l.Logger.Warn("Skipping unmapped nested key", zap.String("nestedKey", nestedKey))
+				}
+				elementRows = append(elementRows, subRows...)
+			} else {
+				// Primitive array element (e.g. "tags": ["a","b"]): one row per value,
+				// placed under the array's own key. Unmapped primitive arrays were
+				// already diverted to the extras column above when one is configured.
+				if !allowed(key) {
+					l.Logger.Warn("Skipping primitive array with unmapped key", zap.String("key", key))
+					l.recordUnmappedKey(key)
+					continue
+				}
+				l.recordArrayKey(key)
+				elementRows = append(elementRows, map[string]interface{}{key: element})
+			}
+		}
+		if len(elementRows) == 0 {
+			continue
+		}
+		hasArrays = true
+
+		// Cross the accumulated rows with this array's rows, nested keys winning on
+		// collisions with base fields
+		expanded := make([]map[string]interface{}, 0, len(rows)*len(elementRows))
+		for _, row := range rows {
+			for _, elementRow := range elementRows {
+				merged := make(map[string]interface{}, len(row)+len(elementRow))
+				for k, v := range row {
+					merged[k] = v
+				}
+				for k, v := range elementRow {
+					// Extras maps from nested rows combine with the base row's
+					// extras instead of replacing them
+					if l.ExtrasColumn != "" && k == l.ExtrasColumn {
+						if baseExtras, ok := merged[k].(map[string]interface{}); ok {
+							if rowExtras, ok := v.(map[string]interface{}); ok {
+								combined := make(map[string]interface{}, len(baseExtras)+len(rowExtras))
+								for extraKey, extraValue := range baseExtras {
+									combined[extraKey] = extraValue
+								}
+								for extraKey, extraValue := range rowExtras {
+									combined[extraKey] = extraValue
+								}
+								merged[k] = combined
+								continue
+							}
 						}
 					}
-					nestedRows = append(nestedRows, flattenedRow)
-				} else {
-					l.Logger.Warn("Skipping unsupported nested element in array", zap.String("key", key))
+					merged[k] = v
 				}
+				expanded = append(expanded, merged)
 			}
-		default:
-			// Add primitive fields to the base record
-			baseRecord[key] = value
 		}
+		rows = expanded
 	}
+	return rows, hasArrays
+}
 
-	// Validate baseRecord keys against allowed columns
-	validatedBaseRecord := make(map[string]interface{})
-	for key, value := range baseRecord {
-		if _, allowed := columnSet[key]; allowed {
-			validatedBaseRecord[key] = value
-		} else {
-			l.Logger.Warn("Skipping unmapped key in base record", zap.String("key", key))
-		}
-	}
-	baseRecord = validatedBaseRecord
 
-	return nestedRows, baseRecord
+// xmlKey renders an XML element name as a flattened-record key. Namespaces are
+// stripped by default so <ns:Widget> and <Widget> both map to the "Widget" column;
+// when PreserveNamespaces is set the key carries the resolved namespace URI
+// (namespace-uri:Widget) for feeds where the same local name means different things
+// in different namespaces.
+func (l *LoaderFunctions) xmlKey(name xml.Name) string {
+	if l.PreserveNamespaces && name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
 }
 
-
 // ParseAndFlattenXMLElementWithColumns parses and flattens an XML element, dynamically handling nested structures.
 // It validates the extracted fields against a provided list of column names.
 //
@@ -379,7 +1051,9 @@ func (l *LoaderFunctions) ParseAndFlattenXMLElementWithColumns(decoder *xml.Deco
 	var nestedRecords []map[string]interface{}
 	var resultRows []map[string]interface{}
 
-	// Create a set of valid column names for efficient validation
+	// Create a set of valid column names for efficient validation.
+	// An empty column list means "accept all keys" (used by schema inference).
+	acceptAll := len(columns) == 0
 	columnSet := make(map[string]bool)
 	for _, col := range columns {
 		columnSet[col] = true
@@ -390,10 +1064,55 @@ func (l *LoaderFunctions) ParseAndFlattenXMLElementWithColumns(decoder *xml.Deco
 	var parseElement func(start xml.StartElement) (map[string]interface{}, error)
 	parseElement = func(start xml.StartElement) (map[string]interface{}, error) {
 		flatRecord := make(map[string]interface{})
-		currentKey := start.Name.Local // Track the current XML element name
+		currentKey := l.xmlKey(start.Name) // Track the current XML element name
 
 		l.Logger.Debug("Parsing XML element", zap.String("element", currentKey))
 
+		// Capture attributes on this element (e.g. <Record id="123" type="x">), keyed by
+		// the attribute's local name with the optional configured prefix. They are
+		// validated against the column list with the rest of the record below.
+		for _, attr := range start.Attr {
+			// Namespace declarations are wiring, not data
+			if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+				continue
+			}
+			attrKey := l.AttributePrefix + attr.Name.Local
+			flatRecord[attrKey] = attr.Value
+			l.Logger.Debug("Captured attribute",
+				zap.String("element", currentKey),
+				zap.String("key", attrKey),
+				zap.String("value", attr.Value))
+		}
+
+		// Accumulate character data until the element closes so split chunks and
+		// mixed content are resolved deterministically in one place
+		var textBuilder strings.Builder
+		hasChildren := false
+		finishText := func() {
+			content := strings.TrimSpace(textBuilder.String())
+			if content == "" {
+				return
+			}
+			if !hasChildren {
+				// Leaf-only element: text maps to the element's own key, as before
+				flatRecord[currentKey] = content
+				l.Logger.Debug("Captured character data", zap.String("key", currentKey), zap.String("value", content))
+				return
+			}
+			// Mixed content: keep the text under the configured key, or drop it
+			if l.XMLMixedContentKey != "" {
+				flatRecord[l.XMLMixedContentKey] = content
+				l.Logger.Debug("Captured mixed-content text",
+					zap.String("element", currentKey),
+					zap.String("key", l.XMLMixedContentKey),
+					zap.String("value", content))
+				return
+			}
+			l.Logger.Debug("Discarding mixed-content text (no XMLMixedContentKey configured)",
+				zap.String("element", currentKey),
+				zap.String("value", content))
+		}
+
 		for {
 			token, err := decoder.Token()
 			if err == io.EOF {
@@ -407,6 +1126,7 @@ func (l *LoaderFunctions) ParseAndFlattenXMLElementWithColumns(decoder *xml.Deco
 			switch t := token.(type) {
 			case xml.StartElement:
 				l.Logger.Debug("Encountered nested start element", zap.String("element", t.Name.Local))
+				hasChildren = true
 				// Recursively parse nested elements
 				nested, err := parseElement(t)
 				if err != nil {
@@ -414,32 +1134,33 @@ func (l *LoaderFunctions) ParseAndFlattenXMLElementWithColumns(decoder *xml.Deco
 					return nil, err
 				}
 				// Handle repeated elements by storing them as slices
-				if existing, exists := flatRecord[t.Name.Local]; exists {
+				nestedKey := l.xmlKey(t.Name)
+				if existing, exists := flatRecord[nestedKey]; exists {
 					if slice, ok := existing.([]map[string]interface{}); ok {
-						flatRecord[t.Name.Local] = append(slice, nested)
+						flatRecord[nestedKey] = append(slice, nested)
 					} else {
-						flatRecord[t.Name.Local] = []map[string]interface{}{existing.(map[string]interface{}), nested}
+						flatRecord[nestedKey] = []map[string]interface{}{existing.(map[string]interface{}), nested}
 					}
 				} else {
-					flatRecord[t.Name.Local] = nested
+					flatRecord[nestedKey] = nested
 				}
 
 			case xml.CharData:
-				// Capture character data as the value for the current element
-				content := strings.TrimSpace(string(t))
-				if content != "" {
-					flatRecord[currentKey] = content
-					l.Logger.Debug("Captured character data", zap.String("key", currentKey), zap.String("value", content))
-				}
+				// Buffer character data; whether it is kept is decided when the
+				// element closes and its children are known
+				textBuilder.Write(t)
 
 			case xml.EndElement:
-				// Return when the current element ends
-				if t.Name.Local == currentKey {
+				// Return when the current element ends; matching is always on the local
+				// name so namespaced elements close correctly
+				if t.Name.Local == start.Name.Local {
+					finishText()
 					l.Logger.Debug("Completed parsing element", zap.String("element", currentKey), zap.Any("record", flatRecord))
 					return flatRecord, nil
 				}
 			}
 		}
+		finishText()
 		return flatRecord, nil
 	}
 
@@ -466,6 +1187,7 @@ func (l *LoaderFunctions) ParseAndFlattenXMLElementWithColumns(decoder *xml.Deco
 				// Add the fields from the nested element
 				for k, v := range nested {
 					flattened[k] = v
+					l.recordArrayKey(k)
 				}
 				l.Logger.Debug("Generated new row for repeated element", zap.String("element", key), zap.Any("row", flattened))
 				// Append the new row directly to the result rows
@@ -488,18 +1210,25 @@ func (l *LoaderFunctions) ParseAndFlattenXMLElementWithColumns(decoder *xml.Deco
 		for k, v := range record {
 			if nestedMap, ok := v.(map[string]interface{}); ok {
 				for nestedKey, nestedValue := range nestedMap {
-					if columnSet[nestedKey] {
+					if acceptAll || columnSet[nestedKey] {
 						flat[nestedKey] = nestedValue
+					} else if l.ExtrasColumn != "" {
+						l.addExtra(flat, nestedKey, nestedValue)
 					} else {
 						l.Logger.Warn("Skipping invalid nested column", zap.String("nestedKey", nestedKey))
+						l.recordUnmappedKey(nestedKey)
 					}
 				}
-			} else if columnSet[k] {
+			} else if acceptAll || columnSet[k] {
 				flat[k] = v
+			} else if l.ExtrasColumn != "" {
+				l.addExtra(flat, k, v)
 			} else {
 				l.Logger.Warn("Skipping invalid column", zap.String("key", k))
+				l.recordUnmappedKey(k)
 			}
 		}
+		l.serializeExtras(flat)
 		nestedRecords[i] = flat
 		l.Logger.Debug("Validated and flattened record", zap.Any("record", flat))
 	}
@@ -510,17 +1239,84 @@ func (l *LoaderFunctions) ParseAndFlattenXMLElementWithColumns(decoder *xml.Deco
 }
 
 
+// ExportToJSON writes records to outputPath as a JSON array, or as NDJSON (one
+// object per line) when configured. Keys are emitted in deterministic order —
+// the configured column list, or sorted — so two exports of the same data diff
+// cleanly. Records are encoded one at a time rather than as a single slice.
+//
+// Parameters:
+// - records: The flattened records to export.
+// - outputPath: The path of the JSON file to create.
+//
+// Returns:
+// - An error if encoding or writing fails.
 func (l *LoaderFunctions) ExportToJSON(records []map[string]interface{}, outputPath string) error {
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to create JSON file: %w", err)
 	}
+	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	if err := encoder.Encode(records); err != nil {
-		return fmt.Errorf("failed to encode JSON: %w", err)
+	writer := bufio.NewWriter(file)
+
+	if l.JSON.NDJSON {
+		// One object per line, encoded record-by-record for piping into other tools
+		for _, record := range records {
+			line, err := l.JSON.marshalRecord(record)
+			if err != nil {
+				return err
+			}
+			if _, err := writer.Write(line); err != nil {
+				return fmt.Errorf("failed to write JSON record: %w", err)
+			}
+			if err := writer.WriteByte('\n'); err != nil {
+				return fmt.Errorf("failed to write JSON record: %w", err)
+			}
+		}
+	} else {
+		if err := writer.WriteByte('['); err != nil {
+			return fmt.Errorf("failed to write JSON array: %w", err)
+		}
+		for i, record := range records {
+			if i > 0 {
+				if err := writer.WriteByte(','); err != nil {
+					return fmt.Errorf("failed to write JSON array: %w", err)
+				}
+			}
+			object, err := l.JSON.marshalRecord(record)
+			if err != nil {
+				return err
+			}
+			if l.JSON.Indent != "" {
+				var indented bytes.Buffer
+				indented.WriteString("\n" + l.JSON.Indent)
+				if err := json.Indent(&indented, object, l.JSON.Indent, l.JSON.Indent); err != nil {
+					return fmt.Errorf("failed to indent JSON record: %w", err)
+				}
+				object = indented.Bytes()
+			}
+			if _, err := writer.Write(object); err != nil {
+				return fmt.Errorf("failed to write JSON record: %w", err)
+			}
+		}
+		if l.JSON.Indent != "" && len(records) > 0 {
+			if err := writer.WriteByte('\n'); err != nil {
+				return fmt.Errorf("failed to write JSON array: %w", err)
+			}
+		}
+		if _, err := writer.WriteString("]\n"); err != nil {
+			return fmt.Errorf("failed to write JSON array: %w", err)
+		}
 	}
-	fmt.Printf("Successfully exported to JSON: %s\n", outputPath)
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush JSON writer: %w", err)
+	}
+	l.Logger.Info("JSON export complete",
+		zap.String("outputPath", outputPath),
+		zap.Int("rows", len(records)),
+		zap.Bool("ndjson", l.JSON.NDJSON),
+	)
 	return nil
 }
 
@@ -531,8 +1327,8 @@ func (l *LoaderFunctions) ExportToCSV(records []map[string]interface{}, outputPa
 		return fmt.Errorf("failed to create CSV file: %w", err)
 	}
 
-	// Initialize the CSV writer
-	writer := csv.NewWriter(file)
+	// Initialize the CSV writer honoring the configured formatting options
+	writer := newCSVRowWriter(file, l.CSV)
 	defer writer.Flush()
 
 	// Check if records are available
@@ -545,22 +1341,22 @@ func (l *LoaderFunctions) ExportToCSV(records []map[string]interface{}, outputPa
 	for key := range records[0] {
 		headers = append(headers, key)
 	}
-	if err := writer.Write(headers); err != nil {
-		return fmt.Errorf("failed to write CSV headers: %w", err)
+	if !l.CSV.OmitHeader {
+		if err := writer.Write(headers); err != nil {
+			return fmt.Errorf("failed to write CSV headers: %w", err)
+		}
 	}
 
-	// Write rows
+	// Write rows; nil and missing values render as the configured null token
 	for _, record := range records {
 		row := []string{}
 		for _, header := range headers {
 			value, exists := record[header]
-			if !exists {
-				row = append(row, "")
+			if !exists || value == nil {
+				row = append(row, l.CSV.NullToken)
 				continue
 			}
-
-			// Convert value to string
-			row = append(row, fmt.Sprintf("%v", value))
+			row = append(row, l.CSV.formatCSVValue(value))
 		}
 
 		if err := writer.Write(row); err != nil {
@@ -572,35 +1368,313 @@ func (l *LoaderFunctions) ExportToCSV(records []map[string]interface{}, outputPa
 	return nil
 }
 
+// ExportToCSVStream writes records to a CSV file as they arrive on the channel,
+// keeping memory constant regardless of input size — unlike ExportToCSV, no slice
+// of records is ever materialized. The header is written immediately from the
+// given column list, so callers can wire StreamDecodeFileWithSchema straight into
+// it for convert-only runs. The writer is flushed and the file closed even when
+// the channel closes early because the upstream decoder failed.
+//
+// Parameters:
+//   - recordChan: Channel of flattened records; the export ends when it closes.
+//   - columns: Column order for the header and every row.
+//   - outputPath: Path of the CSV file to create.
+//
+// Returns:
+//   - error: An error creating the file or writing any row, otherwise nil.
+func (l *LoaderFunctions) ExportToCSVStream(recordChan <-chan map[string]interface{}, columns []string, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := newCSVRowWriter(file, l.CSV)
+	defer writer.Flush()
+
+	// Write the header up front from the schema columns; no need to wait for (or
+	// buffer) the first record
+	if !l.CSV.OmitHeader {
+		if err := writer.Write(columns); err != nil {
+			return fmt.Errorf("failed to write CSV headers: %w", err)
+		}
+	}
+
+	rowCount := 0
+	for record := range recordChan {
+		row := make([]string, 0, len(columns))
+		for _, column := range columns {
+			value, exists := record[column]
+			if !exists || value == nil {
+				row = append(row, l.CSV.NullToken)
+				continue
+			}
+			row = append(row, l.CSV.formatCSVValue(value))
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+		rowCount++
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush CSV writer: %w", err)
+	}
+
+	l.Logger.Info("Streaming CSV export complete",
+		zap.String("outputPath", outputPath),
+		zap.Int("rows", rowCount),
+	)
+	return nil
+}
+
+// ExportToExcel writes records to an Excel workbook, rolling over to a new sheet
+// (Sheet2, Sheet3, ...) whenever a sheet reaches its row capacity so exports larger
+// than the format's row limit are split instead of silently corrupted. Rows are
+// written through excelize's StreamWriter to keep memory flat for large exports.
+//
+// Parameters:
+// - records: The flattened records to export.
+// - outputPath: The path of the workbook to create.
+//
+// Returns:
+// - The list of sheet names written, in order.
+// - An error if writing or saving the workbook fails.
+func (l *LoaderFunctions) ExportToExcel(records []map[string]interface{}, outputPath string) ([]string, error) {
+	// Append mode adds below the existing rows of a workbook that is already on
+	// disk; a missing workbook falls through to the fresh-export path
+	if l.Excel.Append {
+		if _, err := os.Stat(outputPath); err == nil {
+			return l.appendToExcel(records, outputPath)
+		}
+	}
 
-func (l *LoaderFunctions) ExportToExcel(records []map[string]interface{}, outputPath string) error {
 	f := excelize.NewFile()
 
-	// Write headers and rows
-	sheetName := "Sheet1"
-	if len(records) > 0 {
-		headers := []string{}
-		for key := range records[0] {
-			headers = append(headers, key)
+	sheets := []string{}
+	if len(records) == 0 {
+		if err := f.SaveAs(outputPath); err != nil {
+			return nil, fmt.Errorf("failed to save Excel file: %w", err)
+		}
+		return sheets, nil
+	}
+
+	// Derive the header set from the first record
+	headers := []string{}
+	for key := range records[0] {
+		headers = append(headers, key)
+	}
+
+	rowsPerSheet := l.Excel.rowsPerSheet()
+
+	// Resolve styling once; both work through stream-writer-safe excelize APIs
+	headerStyleID, err := l.Excel.headerStyleID(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build header style: %w", err)
+	}
+	widths := l.Excel.columnWidths(headers, records)
+
+	// finishSheet closes out the open stream writer, adding the optional
+	// auto-filter over the sheet's data range first
+	finishSheet := func(sw *excelize.StreamWriter, dataRows int) error {
+		sheetName := sheets[len(sheets)-1]
+		if l.Excel.AutoFilter && dataRows > 0 {
+			lastCell, _ := excelize.CoordinatesToCellName(len(headers), dataRows+1)
+			if err := sw.AddTable(&excelize.Table{Range: "A1:" + lastCell}); err != nil {
+				return fmt.Errorf("failed to add auto-filter on sheet %s: %w", sheetName, err)
+			}
 		}
-		for colIndex, header := range headers {
-			cell, _ := excelize.CoordinatesToCellName(colIndex+1, 1)
-			f.SetCellValue(sheetName, cell, header)
+		if err := sw.Flush(); err != nil {
+			return fmt.Errorf("failed to flush sheet %s: %w", sheetName, err)
 		}
+		return nil
+	}
 
-		// Write rows
-		for rowIndex, record := range records {
-			for colIndex, header := range headers {
-				cell, _ := excelize.CoordinatesToCellName(colIndex+1, rowIndex+2)
-				f.SetCellValue(sheetName, cell, record[header])
+	var sw *excelize.StreamWriter
+	rowInSheet := 0
+	for _, record := range records {
+		// Start a new sheet when there is no open stream writer (first record, or
+		// the previous sheet hit its capacity), repeating the header row
+		if sw == nil {
+			sheetName := fmt.Sprintf("Sheet%d", len(sheets)+1)
+			if len(sheets) > 0 {
+				if _, err := f.NewSheet(sheetName); err != nil {
+					return nil, fmt.Errorf("failed to create sheet %s: %w", sheetName, err)
+				}
+			}
+			sw, err = f.NewStreamWriter(sheetName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create stream writer for sheet %s: %w", sheetName, err)
 			}
+			// Column widths and panes must be set before the first row is written
+			for i, width := range widths {
+				if err := sw.SetColWidth(i+1, i+1, width); err != nil {
+					return nil, fmt.Errorf("failed to set column width on sheet %s: %w", sheetName, err)
+				}
+			}
+			if l.Excel.FreezeHeader {
+				panes := &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}
+				if err := sw.SetPanes(panes); err != nil {
+					return nil, fmt.Errorf("failed to freeze header row on sheet %s: %w", sheetName, err)
+				}
+			}
+			headerRow := make([]interface{}, len(headers))
+			for i, header := range headers {
+				if headerStyleID != 0 {
+					headerRow[i] = excelize.Cell{StyleID: headerStyleID, Value: header}
+				} else {
+					headerRow[i] = header
+				}
+			}
+			if err := sw.SetRow("A1", headerRow); err != nil {
+				return nil, fmt.Errorf("failed to write header row on sheet %s: %w", sheetName, err)
+			}
+			sheets = append(sheets, sheetName)
+			rowInSheet = 0
+		}
+
+		row := make([]interface{}, len(headers))
+		for i, header := range headers {
+			row[i] = record[header]
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, rowInSheet+2)
+		if err := sw.SetRow(cell, row); err != nil {
+			return nil, fmt.Errorf("failed to write row on sheet %s: %w", sheets[len(sheets)-1], err)
+		}
+		rowInSheet++
+
+		// Sheet is full; flush it so the next record opens a fresh one
+		if rowInSheet >= rowsPerSheet {
+			if err := finishSheet(sw, rowInSheet); err != nil {
+				return nil, err
+			}
+			sw = nil
+		}
+	}
+
+	if sw != nil {
+		if err := finishSheet(sw, rowInSheet); err != nil {
+			return nil, err
 		}
 	}
 
 	// Save the Excel file
 	if err := f.SaveAs(outputPath); err != nil {
-		return fmt.Errorf("failed to save Excel file: %w", err)
+		return nil, fmt.Errorf("failed to save Excel file: %w", err)
 	}
-	fmt.Printf("Successfully exported to Excel: %s\n", outputPath)
-	return nil
+	l.Logger.Info("Excel export complete",
+		zap.String("outputPath", outputPath),
+		zap.Int("rows", len(records)),
+		zap.Strings("sheets", sheets),
+	)
+	return sheets, nil
+}
+
+// appendToExcel appends records below the existing rows of the workbook's last
+// sheet, erroring when the sheet's header row does not cover the records'
+// columns. A new sheet is opened automatically when the target sheet would
+// exceed its row capacity. The workbook is saved to a temp file and renamed into
+// place so a crash mid-write cannot corrupt the running workbook.
+//
+// Parameters:
+// - records: The flattened records to append.
+// - outputPath: The path of the existing workbook.
+//
+// Returns:
+// - The list of sheet names appended to, in order.
+// - An error if the header mismatches or writing the workbook fails.
+func (l *LoaderFunctions) appendToExcel(records []map[string]interface{}, outputPath string) ([]string, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	f, err := excelize.OpenFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Excel file for append: %w", err)
+	}
+	defer f.Close()
+
+	// Append to the newest sheet, which is where a previous rollover left off
+	sheetList := f.GetSheetList()
+	if len(sheetList) == 0 {
+		return nil, fmt.Errorf("workbook %s has no sheets to append to", outputPath)
+	}
+	sheetName := sheetList[len(sheetList)-1]
+
+	existingRows, err := f.GetRows(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sheet %s: %w", sheetName, err)
+	}
+	if len(existingRows) == 0 {
+		return nil, fmt.Errorf("sheet %s has no header row to append under", sheetName)
+	}
+	headers := existingRows[0]
+
+	// The existing header must cover exactly the records' columns; appending
+	// rows under a different layout would silently misalign the workbook
+	recordColumns := map[string]bool{}
+	for key := range records[0] {
+		recordColumns[key] = true
+	}
+	if len(headers) != len(recordColumns) {
+		return nil, fmt.Errorf("header mismatch on sheet %s: workbook has %d columns, records have %d", sheetName, len(headers), len(recordColumns))
+	}
+	for _, header := range headers {
+		if !recordColumns[header] {
+			return nil, fmt.Errorf("header mismatch on sheet %s: workbook column %q not present in records", sheetName, header)
+		}
+	}
+
+	rowsPerSheet := l.Excel.rowsPerSheet()
+	nextRow := len(existingRows) + 1
+	sheets := []string{sheetName}
+	for _, record := range records {
+		// Sheet is full (header plus rowsPerSheet data rows); roll over to a new
+		// sheet with a fresh header
+		if nextRow > rowsPerSheet+1 {
+			sheetName = fmt.Sprintf("Sheet%d", len(f.GetSheetList())+1)
+			for idx, _ := f.GetSheetIndex(sheetName); idx >= 0; idx, _ = f.GetSheetIndex(sheetName) {
+				sheetName = sheetName + "_1"
+			}
+			if _, err := f.NewSheet(sheetName); err != nil {
+				return nil, fmt.Errorf("failed to create sheet %s: %w", sheetName, err)
+			}
+			headerRow := make([]interface{}, len(headers))
+			for i, header := range headers {
+				headerRow[i] = header
+			}
+			if err := f.SetSheetRow(sheetName, "A1", &headerRow); err != nil {
+				return nil, fmt.Errorf("failed to write header row on sheet %s: %w", sheetName, err)
+			}
+			sheets = append(sheets, sheetName)
+			nextRow = 2
+		}
+
+		row := make([]interface{}, len(headers))
+		for i, header := range headers {
+			row[i] = record[header]
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, nextRow)
+		if err := f.SetSheetRow(sheetName, cell, &row); err != nil {
+			return nil, fmt.Errorf("failed to append row on sheet %s: %w", sheetName, err)
+		}
+		nextRow++
+	}
+
+	// Write to a temp file and rename so a crash mid-save leaves the original
+	// workbook intact
+	tmpPath := outputPath + ".tmp"
+	if err := f.SaveAs(tmpPath); err != nil {
+		return nil, fmt.Errorf("failed to save Excel file: %w", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return nil, fmt.Errorf("failed to replace Excel file: %w", err)
+	}
+
+	l.Logger.Info("Excel append complete",
+		zap.String("outputPath", outputPath),
+		zap.Int("rows", len(records)),
+		zap.Strings("sheets", sheets),
+	)
+	return sheets, nil
 }
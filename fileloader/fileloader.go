@@ -1,14 +1,20 @@
 package fileloader
 
 import (
+	"compress/gzip"
+	"context"
 	"data-ingestor/config"
+	"data-ingestor/util"
 	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"golang.org/x/text/encoding/htmlindex"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 )
 
 type LoaderFunctionsInterface interface {
@@ -23,18 +29,143 @@ type LoaderFunctionsInterface interface {
 	//ParseAndFlattenXMLElement(decoder *xml.Decoder, start xml.StartElement) ([]map[string]interface{}, error)
 	ExportToJSON(records []map[string]interface{}, outputPath string) error
 	//ExportToCSV(records []map[string]string, outputPath string) error
-	ExportToExcel(records []map[string]interface{}, outputPath string) error
+	ExportToExcel(records []map[string]interface{}, outputPath string) ([]string, error)
 
 }
 
+// ModelKey is the reserved record key carrying the model (source element) name for
+// records produced by StreamXMLMultiModel. It is stripped before insertion and must
+// not collide with a real column name.
+const ModelKey = "__model"
+
 type LoaderFunctions struct {
 	CONFIG *config.Config
 	Logger *zap.Logger
 
+	// RequiredColumns lists columns that must be present in every flattened record.
+	// Records missing any of them are rejected (skipped with a warning) rather than
+	// inserted with NULLs.
+	RequiredColumns []string
+
+	// InputEncoding names the character encoding of the input file (e.g. "windows-1252",
+	// "iso-8859-1"). When set to anything other than UTF-8, input is transcoded to UTF-8
+	// before parsing. Empty means UTF-8 (no transcoding).
+	InputEncoding string
+
+	// XMLMixedContentKey controls what happens to character data inside XML
+	// elements that also contain child elements (mixed content). When set
+	// (commonly "#text"), the trimmed text is kept under that key; when empty,
+	// mixed-content text is discarded with a debug log. Text inside leaf-only
+	// elements always maps to the element's own key, as before.
+	XMLMixedContentKey string
+
+	// ExtrasColumn, when set, collects keys that are not in the allowed column
+	// list into a single map serialized as JSON under this column (typically a
+	// jsonb column), instead of dropping them with a warning. Empty keeps the
+	// historical drop-and-warn behavior.
+	ExtrasColumn string
+
+	// Transforms lists record transformations run, in order, after flattening and
+	// before required-column validation. A transform error rejects the record.
+	Transforms []NamedTransform
+
+	// AttributePrefix is prepended to XML attribute names when they are captured into
+	// flattened records (e.g. "attr_" maps id="123" to attr_id). Empty means the
+	// attribute's local name is used as-is.
+	AttributePrefix string
+
+	// PreserveNamespaces keeps XML namespaces in flattened record keys
+	// (namespace-uri:Element) instead of the default behavior of stripping them and
+	// keying on the element's local name. Record-element detection always matches on
+	// the local name so namespaced feeds parse either way.
+	PreserveNamespaces bool
+
+	// CSV customizes CSV export formatting (delimiter, quoting, line endings, null
+	// rendering). The zero value keeps the historical comma/LF defaults.
+	CSV CSVOptions
+
+	// Excel customizes Excel export behavior (sheet rollover). The zero value keeps
+	// the historical single-sheet output.
+	Excel ExcelExportOptions
+
+	// JSON customizes JSON export formatting (indentation, NDJSON, key ordering).
+	JSON JSONExportOptions
+
+	// Parquet customizes Parquet export behavior (row group sizing). The zero
+	// value uses the default row group size.
+	Parquet ParquetExportOptions
+
+	// MaxFileSizeBytes rejects input files larger than this many bytes with
+	// ErrFileTooLarge before any decoding starts, protecting shared infrastructure
+	// from runaway loads. Zero means unlimited.
+	MaxFileSizeBytes int64
+
+	// ExpectedChecksum, when set, is the digest the input file must hash to before it
+	// is decoded; a mismatch aborts the load with util.ErrChecksumMismatch. Empty
+	// skips validation.
+	ExpectedChecksum string
+	// ChecksumAlgorithm names the hash used with ExpectedChecksum: "md5", "sha256",
+	// or "sha512". Empty defaults to "sha256".
+	ChecksumAlgorithm string
+
+	// PreLoadHook, when set, runs before a file's decoder is opened (e.g. a virus
+	// scan or readiness check). A non-nil error aborts processing of the file and
+	// increments the error counter.
+	PreLoadHook func(ctx context.Context, filePath string) error
+	// PostLoadHook, when set, runs after all of a file's records have been sent
+	// (e.g. notifying a workflow system). It receives the record count, elapsed
+	// time, and the processing error (nil on success); it cannot change the result.
+	PostLoadHook func(ctx context.Context, filePath string, recordCount int, elapsed time.Duration, err error)
+
+	// Counter, when non-nil, accumulates processing statistics shared with the
+	// rest of the pipeline (e.g. pre-load hook failures counted as errors).
+	Counter *util.Counter
+
+	// DeadLetter, when set, receives records rejected during loading (failed
+	// transforms, missing required columns) so they can be fixed and re-submitted.
+	DeadLetter *util.DeadLetterWriter
+	// deadLetterOrdinal tracks the current record's position in the file being
+	// streamed, for dead-letter entries. Reset per file.
+	deadLetterOrdinal int
+
+	// UnmappedKeyCounts, when non-nil, accumulates the keys dropped during flattening
+	// because they are not present in the column list. Populated by sampling runs.
+	UnmappedKeyCounts map[string]int
+	// ArrayKeys, when non-nil, records keys that originated from nested arrays (and so
+	// expand a record into multiple rows). Populated by schema inference runs.
+	ArrayKeys map[string]struct{}
 }
 
 var _ LoaderFunctionsInterface = (*LoaderFunctions)(nil)
 
+// LoaderOption customizes a LoaderFunctions built by NewLoaderFunctions.
+type LoaderOption func(*LoaderFunctions)
+
+// WithPreLoadHook sets the hook run before a file's decoder is opened; its error
+// aborts processing of that file.
+func WithPreLoadHook(hook func(ctx context.Context, filePath string) error) LoaderOption {
+	return func(l *LoaderFunctions) {
+		l.PreLoadHook = hook
+	}
+}
+
+// WithPostLoadHook sets the hook run after all of a file's records have been sent.
+func WithPostLoadHook(hook func(ctx context.Context, filePath string, recordCount int, elapsed time.Duration, err error)) LoaderOption {
+	return func(l *LoaderFunctions) {
+		l.PostLoadHook = hook
+	}
+}
+
+// NewLoaderFunctions constructs a LoaderFunctions with the given config and logger,
+// applying any options.
+func NewLoaderFunctions(cfg *config.Config, logger *zap.Logger, opts ...LoaderOption) *LoaderFunctions {
+	l := &LoaderFunctions{CONFIG: cfg, Logger: logger}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
 // DecodeFile loads the entire file and maps its content to a specified model.
 // It utilizes the createModel function to convert file content into a list of records.
 //
@@ -242,6 +373,40 @@ var _ LoaderFunctionsInterface = (*LoaderFunctions)(nil)
 //}
 
 
+// transcodingReadCloser pairs a transcoding reader with the underlying file so callers
+// can close the file through the usual io.ReadCloser interface.
+type transcodingReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// openInputReader opens the input file, wrapping it in a UTF-8 transcoder when a
+// non-UTF-8 input encoding is configured. Legacy flat-file feeds are frequently
+// Windows-1252 or ISO-8859-1 and would otherwise produce mojibake when read as UTF-8.
+func (l *LoaderFunctions) openInputReader(filePath string) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Default is UTF-8: no transcoding
+	if l.InputEncoding == "" || strings.EqualFold(l.InputEncoding, "utf-8") || strings.EqualFold(l.InputEncoding, "utf8") {
+		return file, nil
+	}
+
+	enc, err := htmlindex.Get(l.InputEncoding)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("unsupported input encoding %q: %w", l.InputEncoding, err)
+	}
+
+	l.Logger.Info("Transcoding input to UTF-8",
+		zap.String("filePath", filePath),
+		zap.String("inputEncoding", l.InputEncoding),
+	)
+	return transcodingReadCloser{Reader: enc.NewDecoder().Reader(file), Closer: file}, nil
+}
+
 // detectFileType detects whether the file is JSON or XML based on the extension or content.
 func (l *LoaderFunctions) detectFileType(filePath string) (string, error) {
 	if strings.HasSuffix(filePath, ".json") {
@@ -252,28 +417,195 @@ func (l *LoaderFunctions) detectFileType(filePath string) (string, error) {
 	return "", errors.New("unsupported file format: must be .json or .xml")
 }
 
+// ErrFileTooLarge reports an input file that exceeds the configured size limit,
+// carrying both sizes so callers can log or match on it with errors.As.
+type ErrFileTooLarge struct {
+	Size int64 // Actual file size in bytes
+	Max  int64 // Configured limit in bytes
+}
+
+func (e *ErrFileTooLarge) Error() string {
+	return fmt.Sprintf("file size %d bytes exceeds the configured limit of %d bytes", e.Size, e.Max)
+}
+
+// ValidateFileChecksum hashes the file with the named algorithm ("md5", "sha256",
+// or "sha512") and compares the digest against the expected one, catching corrupt
+// or incompletely transferred files before any records are decoded.
+//
+// Parameters:
+//   - filePath: Path to the file being validated.
+//   - expectedChecksum: The hex digest the file must hash to.
+//   - algorithm: Hash algorithm name; empty defaults to "sha256".
+//
+// Returns:
+//   - error: *util.ErrChecksumMismatch (matchable with errors.As) when the digests
+//     differ, or any error computing the digest; nil when the file is intact.
+func (l *LoaderFunctions) ValidateFileChecksum(filePath, expectedChecksum, algorithm string) error {
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	actual, err := util.ComputeFileChecksum(filePath, algorithm)
+	if err != nil {
+		return fmt.Errorf("failed to compute %s checksum for %s: %w", algorithm, filePath, err)
+	}
+	if !strings.EqualFold(actual, expectedChecksum) {
+		return &util.ErrChecksumMismatch{
+			Algorithm: algorithm,
+			Expected:  strings.ToLower(expectedChecksum),
+			Actual:    actual,
+		}
+	}
+	return nil
+}
+
+// resolveArchivePath computes the final destination path for an archived file,
+// applying the configured archive layout: an optional date-partitioned subdirectory
+// (2024/06/15/), an optional timestamp suffix on the filename, the naming strategy
+// ("original" keeps the name; "timestamp" appends _20240115T143022Z; "hash"
+// appends the first 12 hex characters of the content's SHA-256), and the collision
+// policy ("suffix"/"rename" appends a numeric counter; "overwrite" replaces;
+// "error"/"fail" fails). The default is the renaming policy so an input file that
+// shares a base name with an already-archived one never silently overwrites it.
+func (l *LoaderFunctions) resolveArchivePath(inputFile, destinationFolder string) (string, error) {
+	now := time.Now()
+	folder := destinationFolder
+	fileName := filepath.Base(inputFile)
 
-// MoveInputFile moves a file from its current location to a specified destination folder.
-// If the destination folder does not exist, it will be created.
+	if l.CONFIG != nil && l.CONFIG.Runtime.ArchiveDatePartition {
+		folder = filepath.Join(folder, now.Format("2006/01/02"))
+	}
+	if l.CONFIG != nil && l.CONFIG.Runtime.ArchiveTimestampSuffix {
+		ext := filepath.Ext(fileName)
+		fileName = strings.TrimSuffix(fileName, ext) + "-" + now.Format("20060102T150405") + ext
+	}
+
+	// Apply the archive naming strategy so redelivered names become unique by
+	// construction rather than relying on the collision policy
+	strategy := ""
+	if l.CONFIG != nil {
+		strategy = l.CONFIG.Runtime.ArchiveNamingStrategy
+	}
+	switch strategy {
+	case "timestamp":
+		ext := filepath.Ext(fileName)
+		fileName = strings.TrimSuffix(fileName, ext) + "_" + now.UTC().Format("20060102T150405Z") + ext
+	case "hash":
+		digest, err := util.ComputeFileChecksum(inputFile, "sha256")
+		if err != nil {
+			return "", fmt.Errorf("failed to hash file for archive naming: %w", err)
+		}
+		ext := filepath.Ext(fileName)
+		fileName = strings.TrimSuffix(fileName, ext) + "_" + digest[:12] + ext
+	}
+
+	destinationPath := filepath.Join(folder, fileName)
+	if _, err := os.Stat(destinationPath); err != nil {
+		if os.IsNotExist(err) {
+			return destinationPath, nil
+		}
+		return "", fmt.Errorf("failed to stat archive destination %s: %w", destinationPath, err)
+	}
+
+	// The destination already exists: apply the collision policy
+	policy := ""
+	if l.CONFIG != nil {
+		policy = l.CONFIG.Runtime.ArchiveCollisionPolicy
+	}
+	switch policy {
+	case "overwrite":
+		l.Logger.Warn("Overwriting existing archived file",
+			zap.String("destinationPath", destinationPath))
+		return destinationPath, nil
+	case "error", "fail":
+		return "", fmt.Errorf("archive destination already exists: %s", destinationPath)
+	default: // "suffix" / "rename"
+		ext := filepath.Ext(fileName)
+		stem := strings.TrimSuffix(fileName, ext)
+		// Match the separator of the naming strategy's suffix (file_d41d8cd98f00_1.xml)
+		separator := "-"
+		if strategy == "timestamp" || strategy == "hash" {
+			separator = "_"
+		}
+		for i := 1; ; i++ {
+			candidate := filepath.Join(folder, fmt.Sprintf("%s%s%d%s", stem, separator, i, ext))
+			if _, err := os.Stat(candidate); os.IsNotExist(err) {
+				return candidate, nil
+			}
+		}
+	}
+}
+
+// MoveInputFile moves a file from its current location to a specified destination folder,
+// applying the configured archive layout (date partitioning, timestamp suffix, collision
+// policy). If the destination folder does not exist, it will be created.
+//
+// Same-filesystem moves use an atomic os.Rename; only cross-device moves (EXDEV) fall
+// back to copy-and-remove, where the copy is staged at destinationPath+".tmp" and
+// renamed into place after an fsync, so the final path never holds a partial file.
+// The original modification time is preserved and the source is deleted only after
+// the archive is durable. When ARCHIVE_COMPRESS is set, the archived copy is
+// gzipped (data.xml -> data.xml.gz).
+//
 // Parameters:
 //   - inputFile: The full path to the file that needs to be moved.
 //   - destinationFolder: The target directory where the file will be moved.
 // Returns:
+//   - string: The final archived path, so callers can report exactly where the file went.
 //   - error: An error if the operation fails, otherwise nil.
-func (l *LoaderFunctions) MoveInputFile(inputFile, destinationFolder string) error {
+func (l *LoaderFunctions) MoveInputFile(inputFile, destinationFolder string) (string, error) {
+	// Resolve the final archived path (date partition, timestamp suffix, collisions)
+	destinationPath, err := l.resolveArchivePath(inputFile, destinationFolder)
+	if err != nil {
+		return "", err
+	}
+
 	// Check if the destination folder exists. If not, create it.
-	if _, err := os.Stat(destinationFolder); os.IsNotExist(err) {
+	if _, err := os.Stat(filepath.Dir(destinationPath)); os.IsNotExist(err) {
 		// Create all necessary directories in the destination path.
-		if err := os.MkdirAll(destinationFolder, os.ModePerm); err != nil {
-			return fmt.Errorf("failed to create destination folder: %w", err)
+		if err := os.MkdirAll(filepath.Dir(destinationPath), os.ModePerm); err != nil {
+			return "", fmt.Errorf("failed to create destination folder: %w", err)
 		}
 	}
 
-	// Extract the base name of the input file (e.g., "file.txt").
-	fileName := filepath.Base(inputFile)
+	compress := l.CONFIG != nil && l.CONFIG.Runtime.ArchiveCompress
+	if compress {
+		destinationPath += ".gz"
+	} else {
+		// Fast path: an atomic rename when source and destination share a filesystem,
+		// avoiding a full byte-for-byte copy of large files. Only a cross-device link
+		// error (EXDEV) falls through to the copy-then-delete path; any other rename
+		// failure is a real error and is surfaced immediately.
+		if err := os.Rename(inputFile, destinationPath); err == nil {
+			l.Logger.Debug("Archived via atomic rename",
+				zap.String("source", inputFile),
+				zap.String("destination", destinationPath),
+			)
+			l.Logger.Info("File moved successfully",
+				zap.String("source", inputFile),
+				zap.String("destination", destinationPath),
+			)
+			return destinationPath, nil
+		} else if !errors.Is(err, syscall.EXDEV) {
+			l.Logger.Error("Failed to rename file",
+				zap.String("source", inputFile),
+				zap.String("destination", destinationPath),
+				zap.Error(err),
+			)
+			return "", fmt.Errorf("failed to rename file: %w", err)
+		}
+	}
 
-	// Construct the full path for the destination file.
-	destinationPath := filepath.Join(destinationFolder, fileName)
+	l.Logger.Debug("Archiving via copy-then-delete",
+		zap.String("source", inputFile),
+		zap.String("destination", destinationPath),
+		zap.Bool("compress", compress),
+	)
+
+	// Stat the source first so its modification time can be preserved on the copy
+	sourceInfo, err := os.Stat(inputFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat source file: %w", err)
+	}
 
 	// Open the source file for reading.
 	sourceFile, err := os.Open(inputFile)
@@ -283,39 +615,80 @@ func (l *LoaderFunctions) MoveInputFile(inputFile, destinationFolder string) err
 			zap.String("inputFile", inputFile),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to open source file: %w", err)
+		return "", fmt.Errorf("failed to open source file: %w", err)
 	}
+	defer sourceFile.Close()
 
-	// Create the destination file for writing.
-	destFile, err := os.Create(destinationPath)
+	// Stage the copy next to the destination and rename into place once it is
+	// complete, so a crash mid-copy never leaves a truncated archive at the
+	// final path
+	tempPath := destinationPath + ".tmp"
+	destFile, err := os.Create(tempPath)
 	if err != nil {
 		// Log the error and return if the destination file cannot be created.
 		l.Logger.Error("Failed to create destination file",
-			zap.String("destinationPath", destinationPath),
+			zap.String("destinationPath", tempPath),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to create destination file: %w", err)
+		return "", fmt.Errorf("failed to create destination file: %w", err)
 	}
 
-	// Copy the contents of the source file to the destination file.
-	if _, err := io.Copy(destFile, sourceFile); err != nil {
+	// Copy the contents of the source file to the destination, gzipping when configured.
+	var copyErr error
+	if compress {
+		gzWriter := gzip.NewWriter(destFile)
+		if _, copyErr = io.Copy(gzWriter, sourceFile); copyErr == nil {
+			copyErr = gzWriter.Close()
+		}
+	} else {
+		_, copyErr = io.Copy(destFile, sourceFile)
+	}
+	if copyErr != nil {
+		destFile.Close()
+		os.Remove(tempPath)
 		// Log the error and return if the copy operation fails.
 		l.Logger.Error("Failed to copy file contents",
 			zap.String("source", inputFile),
 			zap.String("destination", destinationPath),
+			zap.Error(copyErr),
+		)
+		return "", fmt.Errorf("failed to copy file contents: %w", copyErr)
+	}
+
+	// Flush the archived copy to disk before deleting the source, so a crash here
+	// cannot leave us with neither a source nor a durable archive
+	if err := destFile.Sync(); err != nil {
+		destFile.Close()
+		os.Remove(tempPath)
+		return "", fmt.Errorf("failed to sync destination file: %w", err)
+	}
+	if err := destFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return "", fmt.Errorf("failed to close destination file: %w", err)
+	}
+
+	// Preserve the original modification time on the archived copy
+	if err := os.Chtimes(tempPath, sourceInfo.ModTime(), sourceInfo.ModTime()); err != nil {
+		l.Logger.Warn("Failed to preserve modification time on archived file",
+			zap.String("destinationPath", destinationPath),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to copy file contents: %w", err)
+	}
+
+	// Move the completed copy into its final name before removing the source
+	if err := os.Rename(tempPath, destinationPath); err != nil {
+		os.Remove(tempPath)
+		return "", fmt.Errorf("failed to finalize archived file: %w", err)
 	}
 
 	// Remove the original source file after successfully copying its contents.
-	if err := os.RemoveAll(inputFile); err != nil {
+	if err := os.Remove(inputFile); err != nil {
 		// Log the error and return if the original file cannot be removed.
 		l.Logger.Error("Failed to remove original file",
 			zap.String("inputFile", inputFile),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to remove original file: %w", err)
+		return "", fmt.Errorf("failed to remove original file: %w", err)
 	}
 
 	// Log the successful move operation.
@@ -324,5 +697,5 @@ func (l *LoaderFunctions) MoveInputFile(inputFile, destinationFolder string) err
 		zap.String("destination", destinationPath),
 	)
 
-	return nil
+	return destinationPath, nil
 }
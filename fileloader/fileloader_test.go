@@ -0,0 +1,140 @@
+package fileloader
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"data-ingestor/util"
+)
+
+// writeTempFile writes raw bytes to a file in a per-test temp directory.
+func writeTempFile(t *testing.T, name string, contents []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, contents, 0o644); err != nil {
+		t.Fatalf("writing temp file: %v", err)
+	}
+	return path
+}
+
+func TestOpenInputReaderDecodesWindows1252(t *testing.T) {
+	// "José, 1ère entrée" with é (0xE9) and è (0xE8) in Windows-1252
+	raw := []byte("Jos\xe9, 1\xe8re entr\xe9e")
+	path := writeTempFile(t, "legacy.txt", raw)
+
+	l := &LoaderFunctions{Logger: zap.NewNop(), InputEncoding: "windows-1252"}
+	reader, err := l.openInputReader(path)
+	if err != nil {
+		t.Fatalf("openInputReader: %v", err)
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("reading transcoded input: %v", err)
+	}
+	if got, want := string(decoded), "José, 1ère entrée"; got != want {
+		t.Errorf("decoded = %q, want %q", got, want)
+	}
+}
+
+func TestOpenInputReaderPassesThroughUTF8(t *testing.T) {
+	raw := []byte("José")
+	path := writeTempFile(t, "utf8.txt", raw)
+
+	// The default (empty) encoding must not transcode the bytes
+	l := &LoaderFunctions{Logger: zap.NewNop()}
+	reader, err := l.openInputReader(path)
+	if err != nil {
+		t.Fatalf("openInputReader: %v", err)
+	}
+	defer reader.Close()
+
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("reading input: %v", err)
+	}
+	if string(contents) != string(raw) {
+		t.Errorf("contents = %q, want the bytes untouched %q", contents, raw)
+	}
+}
+
+func TestOpenInputReaderRejectsUnknownEncoding(t *testing.T) {
+	path := writeTempFile(t, "input.txt", []byte("data"))
+
+	l := &LoaderFunctions{Logger: zap.NewNop(), InputEncoding: "no-such-encoding"}
+	if _, err := l.openInputReader(path); err == nil {
+		t.Fatal("expected an error for an unsupported encoding")
+	}
+}
+
+func TestMissingRequiredColumns(t *testing.T) {
+	record := map[string]interface{}{"user": "jdoe", "decisionDate": "2026-08-29"}
+
+	if missing := missingRequiredColumns(record, []string{"user", "decisionDate"}); missing != nil {
+		t.Errorf("missing = %v, want nil when all required columns are present", missing)
+	}
+	if got, want := missingRequiredColumns(record, []string{"user", "loadNumber"}), []string{"loadNumber"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missing = %v, want %v", got, want)
+	}
+}
+
+func TestCheckRequiredColumnsRejectsIncompleteRecord(t *testing.T) {
+	l := &LoaderFunctions{Logger: zap.NewNop(), RequiredColumns: []string{"user"}}
+
+	if !l.checkRequiredColumns(map[string]interface{}{"user": "jdoe"}) {
+		t.Error("checkRequiredColumns rejected a complete record")
+	}
+	if l.checkRequiredColumns(map[string]interface{}{"other": 1}) {
+		t.Error("checkRequiredColumns accepted a record missing a required column")
+	}
+}
+
+func TestValidateFileChecksumAcceptsIntactFile(t *testing.T) {
+	path := writeTempFile(t, "load.json", []byte(`[{"user":"a"}]`))
+	digest, err := util.ComputeFileChecksum(path, "sha256")
+	if err != nil {
+		t.Fatalf("ComputeFileChecksum: %v", err)
+	}
+
+	l := &LoaderFunctions{Logger: zap.NewNop()}
+	if err := l.ValidateFileChecksum(path, digest, "sha256"); err != nil {
+		t.Errorf("ValidateFileChecksum on an intact file: %v", err)
+	}
+	// An empty algorithm defaults to sha256
+	if err := l.ValidateFileChecksum(path, digest, ""); err != nil {
+		t.Errorf("ValidateFileChecksum with the default algorithm: %v", err)
+	}
+}
+
+func TestValidateFileChecksumRejectsCorruptedFile(t *testing.T) {
+	path := writeTempFile(t, "load.json", []byte(`[{"user":"a"}]`))
+	digest, err := util.ComputeFileChecksum(path, "sha256")
+	if err != nil {
+		t.Fatalf("ComputeFileChecksum: %v", err)
+	}
+
+	// Corrupt the file after its digest was recorded, as a truncated or
+	// damaged transfer would
+	if err := os.WriteFile(path, []byte(`[{"user":"a"}`), 0o644); err != nil {
+		t.Fatalf("corrupting file: %v", err)
+	}
+
+	l := &LoaderFunctions{Logger: zap.NewNop()}
+	err = l.ValidateFileChecksum(path, digest, "sha256")
+	if err == nil {
+		t.Fatal("expected a checksum mismatch for the corrupted file")
+	}
+	var mismatch *util.ErrChecksumMismatch
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("error = %v, want *util.ErrChecksumMismatch", err)
+	}
+	if mismatch.Algorithm != "sha256" || mismatch.Expected != digest || mismatch.Actual == digest {
+		t.Errorf("mismatch = %+v, want the recorded digest as Expected and a different Actual", mismatch)
+	}
+}
@@ -0,0 +1,115 @@
+package fileloader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RecordFilter is a single column/operator/value predicate evaluated against a flattened
+// record before it is handed to the insert pipeline. Supported operators are
+// "=", "!=", ">", "<", and "contains".
+type RecordFilter struct {
+	Column   string
+	Operator string
+	Value    string
+}
+
+// RecordFilters is the conjunction of all configured filters: a record must match every
+// filter to be loaded.
+type RecordFilters []RecordFilter
+
+// filterOperators lists the supported operators, longest first so "!=" is matched
+// before "=".
+var filterOperators = []string{"!=", ">=", "<=", "=", ">", "<", " contains "}
+
+// ParseFilterExpr parses a filter expression such as `status=Submitted`,
+// `location!=TEST`, or `dt_created>1698412800`.
+func ParseFilterExpr(expr string) (RecordFilter, error) {
+	for _, op := range filterOperators {
+		idx := strings.Index(expr, op)
+		if idx <= 0 {
+			continue
+		}
+		filter := RecordFilter{
+			Column:   strings.TrimSpace(expr[:idx]),
+			Operator: strings.TrimSpace(op),
+			Value:    strings.TrimSpace(expr[idx+len(op):]),
+		}
+		if filter.Column == "" {
+			return RecordFilter{}, fmt.Errorf("filter expression %q has an empty column", expr)
+		}
+		return filter, nil
+	}
+	return RecordFilter{}, fmt.Errorf("filter expression %q has no supported operator (=, !=, >, <, >=, <=, contains)", expr)
+}
+
+// ParseFilterExprs parses a list of filter expressions, failing on the first invalid one.
+func ParseFilterExprs(exprs []string) (RecordFilters, error) {
+	filters := make(RecordFilters, 0, len(exprs))
+	for _, expr := range exprs {
+		filter, err := ParseFilterExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// Matches reports whether the record satisfies the filter. Comparisons are numeric when
+// both sides parse as numbers, and fall back to string comparison otherwise. A record
+// that lacks the filter column does not match.
+func (f RecordFilter) Matches(record map[string]interface{}) bool {
+	raw, ok := record[f.Column]
+	if !ok {
+		return false
+	}
+	actual := fmt.Sprintf("%v", raw)
+
+	switch f.Operator {
+	case "=":
+		return compareFilterValues(actual, f.Value) == 0
+	case "!=":
+		return compareFilterValues(actual, f.Value) != 0
+	case ">":
+		return compareFilterValues(actual, f.Value) > 0
+	case "<":
+		return compareFilterValues(actual, f.Value) < 0
+	case ">=":
+		return compareFilterValues(actual, f.Value) >= 0
+	case "<=":
+		return compareFilterValues(actual, f.Value) <= 0
+	case "contains":
+		return strings.Contains(actual, f.Value)
+	}
+	return false
+}
+
+// MatchesAll reports whether the record satisfies every configured filter.
+func (fs RecordFilters) MatchesAll(record map[string]interface{}) bool {
+	for _, f := range fs {
+		if !f.Matches(record) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareFilterValues compares two values numerically when both parse as numbers,
+// otherwise lexically. Returns -1, 0, or 1.
+func compareFilterValues(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
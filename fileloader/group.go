@@ -0,0 +1,77 @@
+package fileloader
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// GroupRecords collapses flattened rows back into nested records, reversing what
+// flattening did to repeated elements: rows sharing the same values for
+// keyColumns become one record whose nestedColumns are gathered into an array
+// under arrayName (mirroring MistAMSData with its embedded FNumbers). Rows with
+// the same key group correctly even when they are not adjacent; output order is
+// the order each key was first seen. The base record's fields come from the
+// first row of its group, with the nested columns removed.
+func (l *LoaderFunctions) GroupRecords(records []map[string]interface{}, keyColumns []string, nestedColumns []string, arrayName string) []map[string]interface{} {
+	nested := map[string]bool{}
+	for _, column := range nestedColumns {
+		nested[column] = true
+	}
+
+	grouped := map[string]map[string]interface{}{}
+	var order []string
+	for _, record := range records {
+		// Build the group key from the base-record key columns; a separator that
+		// cannot appear in flattened values keeps adjacent keys unambiguous
+		keyParts := make([]string, 0, len(keyColumns))
+		for _, column := range keyColumns {
+			keyParts = append(keyParts, fmt.Sprintf("%v", record[column]))
+		}
+		key := strings.Join(keyParts, "\x00")
+
+		base, seen := grouped[key]
+		if !seen {
+			// First row of the group supplies the base fields
+			base = make(map[string]interface{}, len(record))
+			for column, value := range record {
+				if !nested[column] {
+					base[column] = value
+				}
+			}
+			base[arrayName] = []map[string]interface{}{}
+			grouped[key] = base
+			order = append(order, key)
+		}
+
+		// Gather this row's nested columns into one array element
+		element := map[string]interface{}{}
+		for _, column := range nestedColumns {
+			if value, exists := record[column]; exists && value != nil {
+				element[column] = value
+			}
+		}
+		if len(element) > 0 {
+			base[arrayName] = append(base[arrayName].([]map[string]interface{}), element)
+		}
+	}
+
+	results := make([]map[string]interface{}, 0, len(order))
+	for _, key := range order {
+		results = append(results, grouped[key])
+	}
+
+	l.Logger.Info("Grouped flattened rows into nested records",
+		zap.Int("rows", len(records)),
+		zap.Int("records", len(results)),
+		zap.Strings("keyColumns", keyColumns),
+		zap.String("arrayName", arrayName))
+	return results
+}
+
+// ExportToGroupedJSON groups flattened rows by keyColumns and writes the nested
+// records with ExportToJSON, so vendors receive the original nested shape
+// instead of one row per repeated element.
+func (l *LoaderFunctions) ExportToGroupedJSON(records []map[string]interface{}, keyColumns []string, nestedColumns []string, arrayName string, outputPath string) error {
+	return l.ExportToJSON(l.GroupRecords(records, keyColumns, nestedColumns, arrayName), outputPath)
+}
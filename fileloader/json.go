@@ -0,0 +1,96 @@
+package fileloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// LoadColumnsFromJSON reads a database column list from a JSON file. Two shapes
+// are accepted: a flat array of column names (["user", "status", ...]), or a
+// model mapping in the data-schema.json shape, whose inner values are the column
+// names (emitted in sorted order so the list is deterministic).
+func LoadColumnsFromJSON(path string) ([]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns file %s: %w", path, err)
+	}
+
+	var columns []string
+	if err := json.Unmarshal(raw, &columns); err == nil {
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("columns file %s lists no columns", path)
+		}
+		return columns, nil
+	}
+
+	var mapping map[string]map[string]string
+	if err := json.Unmarshal(raw, &mapping); err != nil {
+		return nil, fmt.Errorf("columns file %s is neither a column array nor a model mapping: %w", path, err)
+	}
+	seen := map[string]struct{}{}
+	for _, keyMap := range mapping {
+		for _, column := range keyMap {
+			if _, dup := seen[column]; !dup {
+				seen[column] = struct{}{}
+				columns = append(columns, column)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("columns file %s lists no columns", path)
+	}
+	sort.Strings(columns)
+	return columns, nil
+}
+
+// JSONExportOptions customizes JSON export formatting. The zero value produces a
+// compact array like the historical output, but with deterministic (sorted) key
+// order so two exports of the same data diff cleanly.
+type JSONExportOptions struct {
+	// Indent pretty-prints the array output with the given indent string
+	// (e.g. "  "). Empty keeps compact output. Ignored for NDJSON, which is
+	// always one object per line.
+	Indent string
+	// NDJSON writes one JSON object per line instead of a single array, encoding
+	// record-by-record so large exports are not buffered whole.
+	NDJSON bool
+	// Columns fixes the key order of every object; keys absent from a record
+	// render as null. Empty means each record's keys are sorted alphabetically.
+	Columns []string
+}
+
+// marshalRecord renders one record as a JSON object with deterministic key order.
+func (o JSONExportOptions) marshalRecord(record map[string]interface{}) ([]byte, error) {
+	keys := o.Columns
+	if len(keys) == 0 {
+		keys = make([]string, 0, len(record))
+		for key := range record {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyJSON, err := json.Marshal(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode key %q: %w", key, err)
+		}
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		valueJSON, err := json.Marshal(record[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode column %q: %w", key, err)
+		}
+		buf.Write(valueJSON)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
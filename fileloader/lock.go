@@ -0,0 +1,87 @@
+package fileloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// lockFileSuffix is appended to an input file's path to form its claim sidecar
+// (e.g. data.xml -> data.xml.lock).
+const lockFileSuffix = ".lock"
+
+// defaultLockStaleAfter is how old a lock may grow before it is considered
+// abandoned and reclaimable, used when no age is configured.
+const defaultLockStaleAfter = time.Hour
+
+// ErrFileAlreadyClaimed indicates another run holds a live claim on the file, so this
+// run should skip it rather than double-ingest.
+var ErrFileAlreadyClaimed = errors.New("input file is already claimed by another run")
+
+// ClaimInputFile takes an exclusive claim on an input file by creating a .lock sidecar
+// with O_EXCL, so overlapping runs cannot both ingest the same file. The sidecar
+// records the claiming PID, host, and time for operators debugging a stuck claim.
+// Locks older than staleAfter are treated as abandoned (e.g. a crashed run) and
+// reclaimed with a warning; staleAfter <= 0 uses a one-hour default.
+//
+// Parameters:
+//   - filePath: The input file to claim.
+//   - staleAfter: Age beyond which an existing lock is considered stale.
+//
+// Returns:
+//   - error: ErrFileAlreadyClaimed when a live claim exists, or any filesystem error.
+func (l *LoaderFunctions) ClaimInputFile(filePath string, staleAfter time.Duration) error {
+	if staleAfter <= 0 {
+		staleAfter = defaultLockStaleAfter
+	}
+	lockPath := filePath + lockFileSuffix
+
+	for {
+		lockFile, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			hostname, _ := os.Hostname()
+			fmt.Fprintf(lockFile, "pid=%d host=%s claimed_at=%s\n", os.Getpid(), hostname, time.Now().UTC().Format(time.RFC3339))
+			lockFile.Close()
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to create lock file %s: %w", lockPath, err)
+		}
+
+		// A lock already exists; check whether its holder is stale
+		info, statErr := os.Stat(lockPath)
+		if statErr != nil {
+			if os.IsNotExist(statErr) {
+				continue // Holder released it between our attempts; retry the claim
+			}
+			return fmt.Errorf("failed to stat lock file %s: %w", lockPath, statErr)
+		}
+		if time.Since(info.ModTime()) < staleAfter {
+			return ErrFileAlreadyClaimed
+		}
+
+		// Stale lock: the claiming run likely crashed. Reclaim it with a warning.
+		l.Logger.Warn("Reclaiming stale input file lock",
+			zap.String("lockPath", lockPath),
+			zap.Duration("age", time.Since(info.ModTime())),
+			zap.Duration("staleAfter", staleAfter),
+		)
+		if removeErr := os.Remove(lockPath); removeErr != nil && !os.IsNotExist(removeErr) {
+			return fmt.Errorf("failed to remove stale lock file %s: %w", lockPath, removeErr)
+		}
+	}
+}
+
+// ReleaseInputFile removes the claim sidecar created by ClaimInputFile. It is safe to
+// call after the input file itself has been archived or quarantined, since the lock
+// lives next to the original path.
+func (l *LoaderFunctions) ReleaseInputFile(filePath string) error {
+	lockPath := filePath + lockFileSuffix
+	if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file %s: %w", lockPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,513 @@
+package fileloader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"go.uber.org/zap"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParquetExportOptions customizes Parquet export behavior.
+type ParquetExportOptions struct {
+	// RowGroupSize is the number of rows buffered into each row group before it
+	// is flushed to the file, bounding memory on large exports. Zero or negative
+	// uses the default of 10000 rows.
+	RowGroupSize int
+}
+
+// defaultParquetRowGroupSize bounds the rows buffered per row group when no
+// explicit size is configured.
+const defaultParquetRowGroupSize = 10000
+
+// Parquet physical types (format spec Type enum), limited to the ones the
+// exporter emits.
+const (
+	parquetTypeBoolean   = 0
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+)
+
+// Parquet converted types (format spec ConvertedType enum) annotating the
+// physical types above.
+const (
+	parquetConvertedNone            = -1
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+)
+
+// parquetColumn is one column's resolved physical representation.
+type parquetColumn struct {
+	name          string
+	physicalType  int32
+	convertedType int32
+	timestamp     bool
+}
+
+// resolveParquetColumn maps a template type name to its Parquet representation.
+// Both the template's own names (string, int64, double, bool, timestamp) and
+// the TypeMapping coercion names (float64, time.RFC3339) are accepted; unknown
+// or missing types fall back to UTF8 strings.
+func resolveParquetColumn(name, typeName string) parquetColumn {
+	switch strings.ToLower(typeName) {
+	case "int64", "int32", "int":
+		return parquetColumn{name: name, physicalType: parquetTypeInt64, convertedType: parquetConvertedNone}
+	case "double", "float64", "float":
+		return parquetColumn{name: name, physicalType: parquetTypeDouble, convertedType: parquetConvertedNone}
+	case "bool", "boolean":
+		return parquetColumn{name: name, physicalType: parquetTypeBoolean, convertedType: parquetConvertedNone}
+	case "timestamp", "timestamp_millis", "time.rfc3339":
+		return parquetColumn{name: name, physicalType: parquetTypeInt64, convertedType: parquetConvertedTimestampMillis, timestamp: true}
+	default:
+		return parquetColumn{name: name, physicalType: parquetTypeByteArray, convertedType: parquetConvertedUTF8}
+	}
+}
+
+// thriftEncoder writes Thrift compact protocol, which Parquet uses for its page
+// headers and file footer. Only the subset the exporter needs is implemented:
+// i32/i64 (zigzag varints), binary, lists, and nested structs.
+type thriftEncoder struct {
+	buf  bytes.Buffer
+	last []int16 // stack of the previous field id per open struct
+}
+
+// Thrift compact type codes for the field and element types the encoder emits.
+const (
+	thriftTypeI32    = 5
+	thriftTypeI64    = 6
+	thriftTypeBinary = 8
+	thriftTypeList   = 9
+	thriftTypeStruct = 12
+)
+
+func (e *thriftEncoder) varint(v uint64) {
+	for v >= 0x80 {
+		e.buf.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	e.buf.WriteByte(byte(v))
+}
+
+func zigzag32(v int32) uint64 { return uint64(uint32((v << 1) ^ (v >> 31))) }
+func zigzag64(v int64) uint64 { return uint64((v << 1) ^ (v >> 63)) }
+
+func (e *thriftEncoder) beginStruct() { e.last = append(e.last, 0) }
+
+func (e *thriftEncoder) endStruct() {
+	e.buf.WriteByte(0) // stop field
+	e.last = e.last[:len(e.last)-1]
+}
+
+// fieldHeader writes the compact field header: a short form when the id is
+// within 15 of the previous field, the long form otherwise. Fields must be
+// written in ascending id order within a struct.
+func (e *thriftEncoder) fieldHeader(id int16, fieldType byte) {
+	delta := id - e.last[len(e.last)-1]
+	if delta > 0 && delta <= 15 {
+		e.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		e.buf.WriteByte(fieldType)
+		e.varint(zigzag32(int32(id)))
+	}
+	e.last[len(e.last)-1] = id
+}
+
+func (e *thriftEncoder) fieldI32(id int16, v int32) {
+	e.fieldHeader(id, thriftTypeI32)
+	e.varint(zigzag32(v))
+}
+
+func (e *thriftEncoder) fieldI64(id int16, v int64) {
+	e.fieldHeader(id, thriftTypeI64)
+	e.varint(zigzag64(v))
+}
+
+func (e *thriftEncoder) fieldBinary(id int16, b []byte) {
+	e.fieldHeader(id, thriftTypeBinary)
+	e.varint(uint64(len(b)))
+	e.buf.Write(b)
+}
+
+func (e *thriftEncoder) fieldList(id int16, elemType byte, size int) {
+	e.fieldHeader(id, thriftTypeList)
+	if size < 15 {
+		e.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		e.buf.WriteByte(0xF0 | elemType)
+		e.varint(uint64(size))
+	}
+}
+
+func (e *thriftEncoder) fieldStruct(id int16) {
+	e.fieldHeader(id, thriftTypeStruct)
+	e.beginStruct()
+}
+
+// listI32 and listBinary write one list element of the respective type.
+func (e *thriftEncoder) listI32(v int32) { e.varint(zigzag32(v)) }
+
+func (e *thriftEncoder) listBinary(b []byte) {
+	e.varint(uint64(len(b)))
+	e.buf.Write(b)
+}
+
+// coerceParquetValue converts a record value to the column's physical
+// representation: int64, float64, bool, or []byte. A nil input stays nil and is
+// written as a NULL via the definition levels.
+func coerceParquetValue(col parquetColumn, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if col.timestamp {
+		switch v := value.(type) {
+		case time.Time:
+			return v.UnixMilli(), nil
+		case string:
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q as an RFC3339 timestamp: %w", v, err)
+			}
+			return parsed.UnixMilli(), nil
+		case int64:
+			return v, nil
+		default:
+			return nil, fmt.Errorf("cannot convert %T to a timestamp", value)
+		}
+	}
+	switch col.physicalType {
+	case parquetTypeInt64:
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case int:
+			return int64(v), nil
+		case int32:
+			return int64(v), nil
+		case float64:
+			return int64(v), nil
+		case string:
+			parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q as int64: %w", v, err)
+			}
+			return parsed, nil
+		default:
+			return nil, fmt.Errorf("cannot convert %T to int64", value)
+		}
+	case parquetTypeDouble:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case int:
+			return float64(v), nil
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q as double: %w", v, err)
+			}
+			return parsed, nil
+		default:
+			return nil, fmt.Errorf("cannot convert %T to double", value)
+		}
+	case parquetTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q as bool: %w", v, err)
+			}
+			return parsed, nil
+		default:
+			return nil, fmt.Errorf("cannot convert %T to bool", value)
+		}
+	default: // BYTE_ARRAY / UTF8
+		switch v := value.(type) {
+		case string:
+			return []byte(v), nil
+		case []byte:
+			return v, nil
+		default:
+			return []byte(fmt.Sprintf("%v", value)), nil
+		}
+	}
+}
+
+// encodeParquetPage renders one data page for a column: the RLE-encoded
+// definition levels (length-prefixed, bit width 1) followed by the PLAIN-encoded
+// non-null values. Repetition levels are omitted because the schema is flat.
+func encodeParquetPage(col parquetColumn, values []interface{}) []byte {
+	// Definition levels: runs of present (1) / null (0), RLE-encoded
+	var levels bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+	for i := 0; i < len(values); {
+		present := values[i] != nil
+		j := i
+		for j < len(values) && (values[j] != nil) == present {
+			j++
+		}
+		n := binary.PutUvarint(scratch[:], uint64(j-i)<<1)
+		levels.Write(scratch[:n])
+		if present {
+			levels.WriteByte(1)
+		} else {
+			levels.WriteByte(0)
+		}
+		i = j
+	}
+
+	var page bytes.Buffer
+	var lenPrefix [4]byte
+	binary.LittleEndian.PutUint32(lenPrefix[:], uint32(levels.Len()))
+	page.Write(lenPrefix[:])
+	page.Write(levels.Bytes())
+
+	// Values: PLAIN encoding of the non-null entries only
+	var word [8]byte
+	bitBuf := byte(0)
+	bitCount := 0
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+		switch col.physicalType {
+		case parquetTypeBoolean:
+			if value.(bool) {
+				bitBuf |= 1 << bitCount
+			}
+			bitCount++
+			if bitCount == 8 {
+				page.WriteByte(bitBuf)
+				bitBuf, bitCount = 0, 0
+			}
+		case parquetTypeInt64:
+			binary.LittleEndian.PutUint64(word[:], uint64(value.(int64)))
+			page.Write(word[:])
+		case parquetTypeDouble:
+			binary.LittleEndian.PutUint64(word[:], math.Float64bits(value.(float64)))
+			page.Write(word[:])
+		default: // BYTE_ARRAY
+			b := value.([]byte)
+			binary.LittleEndian.PutUint32(lenPrefix[:], uint32(len(b)))
+			page.Write(lenPrefix[:])
+			page.Write(b)
+		}
+	}
+	if bitCount > 0 {
+		page.WriteByte(bitBuf)
+	}
+	return page.Bytes()
+}
+
+// encodeParquetPageHeader renders the Thrift page header preceding each data
+// page. The codec is UNCOMPRESSED, so the compressed and uncompressed sizes
+// are identical.
+func encodeParquetPageHeader(numValues, pageSize int) []byte {
+	e := &thriftEncoder{}
+	e.beginStruct()
+	e.fieldI32(1, 0)               // type: DATA_PAGE
+	e.fieldI32(2, int32(pageSize)) // uncompressed_page_size
+	e.fieldI32(3, int32(pageSize)) // compressed_page_size
+	e.fieldStruct(5)               // data_page_header
+	e.fieldI32(1, int32(numValues))
+	e.fieldI32(2, 0) // encoding: PLAIN
+	e.fieldI32(3, 3) // definition_level_encoding: RLE
+	e.fieldI32(4, 3) // repetition_level_encoding: RLE
+	e.endStruct()
+	e.endStruct()
+	return e.buf.Bytes()
+}
+
+// parquetChunkInfo records where one column chunk landed in the file, for the
+// footer metadata.
+type parquetChunkInfo struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+// parquetGroupInfo records one written row group for the footer metadata.
+type parquetGroupInfo struct {
+	chunks    []parquetChunkInfo
+	numRows   int64
+	totalSize int64
+}
+
+// encodeParquetFooter renders the FileMetaData footer: a flat schema of
+// OPTIONAL columns under the root element, and one RowGroup entry per flushed
+// group.
+func encodeParquetFooter(cols []parquetColumn, groups []parquetGroupInfo, numRows int64) []byte {
+	e := &thriftEncoder{}
+	e.beginStruct()
+	e.fieldI32(1, 1) // version
+
+	// Schema: root element followed by one OPTIONAL element per column
+	e.fieldList(2, thriftTypeStruct, len(cols)+1)
+	e.beginStruct()
+	e.fieldBinary(4, []byte("schema"))
+	e.fieldI32(5, int32(len(cols)))
+	e.endStruct()
+	for _, col := range cols {
+		e.beginStruct()
+		e.fieldI32(1, col.physicalType)
+		e.fieldI32(3, 1) // repetition_type: OPTIONAL
+		e.fieldBinary(4, []byte(col.name))
+		if col.convertedType != parquetConvertedNone {
+			e.fieldI32(6, col.convertedType)
+		}
+		e.endStruct()
+	}
+
+	e.fieldI64(3, numRows)
+
+	e.fieldList(4, thriftTypeStruct, len(groups))
+	for _, group := range groups {
+		e.beginStruct()
+		e.fieldList(1, thriftTypeStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			e.beginStruct()
+			e.fieldI64(2, chunk.offset) // file_offset
+			e.fieldStruct(3)            // meta_data
+			e.fieldI32(1, cols[i].physicalType)
+			e.fieldList(2, thriftTypeI32, 2)    // encodings
+			e.listI32(0)                        // PLAIN
+			e.listI32(3)                        // RLE
+			e.fieldList(3, thriftTypeBinary, 1) // path_in_schema
+			e.listBinary([]byte(cols[i].name))
+			e.fieldI32(4, 0) // codec: UNCOMPRESSED
+			e.fieldI64(5, chunk.numValues)
+			e.fieldI64(6, chunk.size)   // total_uncompressed_size
+			e.fieldI64(7, chunk.size)   // total_compressed_size
+			e.fieldI64(9, chunk.offset) // data_page_offset
+			e.endStruct()
+			e.endStruct()
+		}
+		e.fieldI64(2, group.totalSize)
+		e.fieldI64(3, group.numRows)
+		e.endStruct()
+	}
+
+	e.fieldBinary(6, []byte("data-ingestor"))
+	e.endStruct()
+	return e.buf.Bytes()
+}
+
+// ExportToParquet writes records to outputPath as a Parquet file for downstream
+// analytics. Column types come from columnTypes (string, int64, double, bool,
+// timestamp — the TypeMapping names float64 and time.RFC3339 are also accepted);
+// columns without an entry are written as UTF8 strings. Every column is OPTIONAL,
+// so keys missing from a record become NULLs. Rows are written in row groups of
+// the configured size (ParquetExportOptions.RowGroupSize, default 10000) so
+// memory stays bounded on large exports. Pages are PLAIN-encoded and
+// uncompressed; no external Parquet dependency is required.
+//
+// Parameters:
+// - records: The flattened records to export.
+// - columns: The column list, in output order.
+// - columnTypes: Column name to type name; nil or missing entries mean string.
+// - outputPath: The path of the Parquet file to create.
+//
+// Returns:
+// - An error if a value cannot be converted to its column type or writing fails.
+func (l *LoaderFunctions) ExportToParquet(records []map[string]interface{}, columns []string, columnTypes map[string]string, outputPath string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("parquet export requires a column list")
+	}
+
+	rowGroupSize := l.Parquet.RowGroupSize
+	if rowGroupSize <= 0 {
+		rowGroupSize = defaultParquetRowGroupSize
+	}
+
+	cols := make([]parquetColumn, len(columns))
+	for i, name := range columns {
+		cols[i] = resolveParquetColumn(name, columnTypes[name])
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create Parquet file: %w", err)
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+
+	if _, err := writer.WriteString("PAR1"); err != nil {
+		return fmt.Errorf("failed to write Parquet header: %w", err)
+	}
+	offset := int64(4)
+
+	// Flush the records one row group at a time
+	var groups []parquetGroupInfo
+	for start := 0; start < len(records); start += rowGroupSize {
+		end := start + rowGroupSize
+		if end > len(records) {
+			end = len(records)
+		}
+		group := records[start:end]
+		info := parquetGroupInfo{numRows: int64(len(group))}
+
+		for _, col := range cols {
+			values := make([]interface{}, len(group))
+			for j, record := range group {
+				coerced, err := coerceParquetValue(col, record[col.name])
+				if err != nil {
+					return fmt.Errorf("column %q: %w", col.name, err)
+				}
+				values[j] = coerced
+			}
+
+			page := encodeParquetPage(col, values)
+			header := encodeParquetPageHeader(len(values), len(page))
+			if _, err := writer.Write(header); err != nil {
+				return fmt.Errorf("failed to write Parquet page: %w", err)
+			}
+			if _, err := writer.Write(page); err != nil {
+				return fmt.Errorf("failed to write Parquet page: %w", err)
+			}
+
+			chunk := parquetChunkInfo{
+				offset:    offset,
+				size:      int64(len(header) + len(page)),
+				numValues: int64(len(values)),
+			}
+			offset += chunk.size
+			info.chunks = append(info.chunks, chunk)
+			info.totalSize += chunk.size
+		}
+		groups = append(groups, info)
+	}
+
+	// Footer metadata, its length, and the trailing magic
+	footer := encodeParquetFooter(cols, groups, int64(len(records)))
+	if _, err := writer.Write(footer); err != nil {
+		return fmt.Errorf("failed to write Parquet footer: %w", err)
+	}
+	var footerLen [4]byte
+	binary.LittleEndian.PutUint32(footerLen[:], uint32(len(footer)))
+	if _, err := writer.Write(footerLen[:]); err != nil {
+		return fmt.Errorf("failed to write Parquet footer: %w", err)
+	}
+	if _, err := writer.WriteString("PAR1"); err != nil {
+		return fmt.Errorf("failed to write Parquet footer: %w", err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush Parquet file: %w", err)
+	}
+
+	l.Logger.Info("Successfully exported records to Parquet",
+		zap.String("outputPath", outputPath),
+		zap.Int("recordCount", len(records)),
+		zap.Int("rowGroups", len(groups)))
+	return nil
+}
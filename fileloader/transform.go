@@ -0,0 +1,165 @@
+package fileloader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TransformFunc rewrites a flattened record in place between parsing and column
+// validation. It returns the (possibly replaced) record, or an error to reject it.
+type TransformFunc func(record map[string]interface{}) (map[string]interface{}, error)
+
+// NamedTransform pairs a TransformFunc with the registry name it was built from so
+// rejection messages can identify which transform failed.
+type NamedTransform struct {
+	Name string
+	Fn   TransformFunc
+}
+
+// TransformBuilder constructs a TransformFunc from the argument portion of a transform
+// spec (everything after the first ":").
+type TransformBuilder func(arg string) (TransformFunc, error)
+
+// transformRegistry maps transform names to their builders. Built-ins are registered
+// below; deployments can add their own via RegisterTransform before parsing specs.
+var transformRegistry = map[string]TransformBuilder{
+	"rename":       buildRenameTransform,
+	"constant":     buildConstantTransform,
+	"template":     buildTemplateTransform,
+	"epoch-to-iso": buildEpochToISOTransform,
+}
+
+// RegisterTransform adds (or replaces) a named transform builder in the registry.
+func RegisterTransform(name string, builder TransformBuilder) {
+	transformRegistry[name] = builder
+}
+
+// ParseTransformSpec parses a single transform spec of the form "name:arg", e.g.
+// "rename:dtCreated=dt_created", "constant:source=mist", "template:label={user}-{f_number}",
+// or "epoch-to-iso:dt_created".
+func ParseTransformSpec(spec string) (NamedTransform, error) {
+	name, arg, found := strings.Cut(spec, ":")
+	if !found || strings.TrimSpace(name) == "" {
+		return NamedTransform{}, fmt.Errorf("transform spec %q must be of the form name:arg", spec)
+	}
+	name = strings.TrimSpace(name)
+
+	builder, ok := transformRegistry[name]
+	if !ok {
+		return NamedTransform{}, fmt.Errorf("unknown transform %q in spec %q", name, spec)
+	}
+
+	fn, err := builder(strings.TrimSpace(arg))
+	if err != nil {
+		return NamedTransform{}, fmt.Errorf("invalid %q transform spec %q: %w", name, spec, err)
+	}
+	return NamedTransform{Name: name, Fn: fn}, nil
+}
+
+// ParseTransformSpecs parses an ordered list of transform specs, failing on the first
+// invalid one. Transforms run in the order given.
+func ParseTransformSpecs(specs []string) ([]NamedTransform, error) {
+	transforms := make([]NamedTransform, 0, len(specs))
+	for _, spec := range specs {
+		transform, err := ParseTransformSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, transform)
+	}
+	return transforms, nil
+}
+
+// buildRenameTransform renames a key: arg is "oldKey=newKey". Records without the old
+// key are passed through unchanged.
+func buildRenameTransform(arg string) (TransformFunc, error) {
+	oldKey, newKey, found := strings.Cut(arg, "=")
+	if !found || oldKey == "" || newKey == "" {
+		return nil, fmt.Errorf("expected oldKey=newKey, got %q", arg)
+	}
+	return func(record map[string]interface{}) (map[string]interface{}, error) {
+		if value, ok := record[oldKey]; ok {
+			record[newKey] = value
+			delete(record, oldKey)
+		}
+		return record, nil
+	}, nil
+}
+
+// buildConstantTransform sets a column to a fixed value: arg is "column=value". An
+// existing value is overwritten.
+func buildConstantTransform(arg string) (TransformFunc, error) {
+	column, value, found := strings.Cut(arg, "=")
+	if !found || column == "" {
+		return nil, fmt.Errorf("expected column=value, got %q", arg)
+	}
+	return func(record map[string]interface{}) (map[string]interface{}, error) {
+		record[column] = value
+		return record, nil
+	}, nil
+}
+
+// buildTemplateTransform derives a column from other fields: arg is "column=template"
+// where {key} placeholders in the template are replaced with the record's values.
+// A placeholder referencing a missing key rejects the record.
+func buildTemplateTransform(arg string) (TransformFunc, error) {
+	column, template, found := strings.Cut(arg, "=")
+	if !found || column == "" || template == "" {
+		return nil, fmt.Errorf("expected column=template, got %q", arg)
+	}
+	return func(record map[string]interface{}) (map[string]interface{}, error) {
+		result := template
+		for {
+			start := strings.Index(result, "{")
+			if start < 0 {
+				break
+			}
+			end := strings.Index(result[start:], "}")
+			if end < 0 {
+				break
+			}
+			key := result[start+1 : start+end]
+			value, ok := record[key]
+			if !ok {
+				return nil, fmt.Errorf("template references missing key %q", key)
+			}
+			result = result[:start] + fmt.Sprintf("%v", value) + result[start+end+1:]
+		}
+		record[column] = result
+		return record, nil
+	}, nil
+}
+
+// buildEpochToISOTransform converts an epoch-seconds column to an RFC3339 UTC
+// timestamp: arg is the column name. Records without the column pass through; a value
+// that does not parse as an integer rejects the record.
+func buildEpochToISOTransform(arg string) (TransformFunc, error) {
+	if arg == "" {
+		return nil, fmt.Errorf("expected a column name")
+	}
+	return func(record map[string]interface{}) (map[string]interface{}, error) {
+		raw, ok := record[arg]
+		if !ok {
+			return record, nil
+		}
+		var epoch int64
+		switch v := raw.(type) {
+		case int64:
+			epoch = v
+		case float64:
+			epoch = int64(v)
+		case string:
+			parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("column %q value %q is not an epoch timestamp: %w", arg, v, err)
+			}
+			epoch = parsed
+		default:
+			return nil, fmt.Errorf("column %q value %v (%T) is not an epoch timestamp", arg, raw, raw)
+		}
+		record[arg] = time.Unix(epoch, 0).UTC().Format(time.RFC3339)
+		return record, nil
+	}, nil
+}
@@ -0,0 +1,6 @@
+// Package integration holds end-to-end pipeline tests that run against a real
+// (file-backed) database instead of per-package stubs. The tests use SQLite so
+// they can run in CI without a database server; they skip themselves unless the
+// binary was built with the sqlite driver compiled in (go test -tags sqlite
+// ./integration/ after "go get modernc.org/sqlite && go mod vendor").
+package integration
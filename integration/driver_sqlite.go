@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package integration
+
+// Registers the pure-Go "sqlite" driver the pipeline test opens; see doc.go
+// for how to enable it.
+import _ "modernc.org/sqlite"
@@ -1,16 +1,259 @@
 package mapreduce
 
 import (
+	"context"
 	"data-ingestor/util"
 	"database/sql"
+	"errors"
+	"fmt"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
 )
 
+// logger carries the package's progress, scaler, and loader-failure logging.
+// The no-op default is replaced via SetLogger so output flows through the
+// binary's shared zap logger.
+var logger = zap.NewNop()
+
+// SetLogger routes this package's logging through l. Passing nil restores the
+// no-op default.
+func SetLogger(l *zap.Logger) {
+	if l == nil {
+		l = zap.NewNop()
+	}
+	logger = l
+}
+
+// progressInterval controls how often streaming runs emit a throughput progress line.
+const progressInterval = 10 * time.Second
+
+// scaleInterval controls how often a streaming run with a WorkerScaler samples the
+// task queue and adjusts the worker count. It is a variable so tests can tighten it.
+var scaleInterval = 5 * time.Second
+
+// Default channel capacities for streaming runs. Larger buffers use more memory but
+// reduce goroutine blocking between the loader, workers, and reducer; override them
+// with WithChannelBuffers (config RUNTIME.*_CHAN_BUFFER).
+const (
+	defaultRecordChanBuffer = 1000
+	defaultTaskChanBuffer   = 1000
+)
+
+// Circuit breaker states.
+const (
+	breakerClosed int32 = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker trips open after a run of consecutive map failures so workers stop
+// hammering a database that is down. While open, records are failed fast without
+// calling the map function; after resetTimeout a single probe is allowed through
+// (half-open), and its outcome either closes or re-opens the breaker. All state is
+// atomic so the breaker can be shared by every worker in a run.
+type CircuitBreaker struct {
+	failureThreshold int
+	resetTimeout     time.Duration
+	state            int32 // breakerClosed / breakerOpen / breakerHalfOpen
+	consecutiveFails int32 // consecutive failures while closed
+	openedAt         int64 // UnixNano of the moment the breaker opened
+}
+
+// NewCircuitBreaker builds a breaker that opens after threshold consecutive failures
+// and allows a probe after timeout.
+func NewCircuitBreaker(threshold int, timeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{failureThreshold: threshold, resetTimeout: timeout}
+}
+
+// Allow reports whether a map call may proceed. While open it returns false until
+// resetTimeout has elapsed, at which point exactly one caller wins the transition to
+// half-open and probes the database.
+func (cb *CircuitBreaker) Allow() bool {
+	switch atomic.LoadInt32(&cb.state) {
+	case breakerOpen:
+		openedAt := atomic.LoadInt64(&cb.openedAt)
+		if time.Since(time.Unix(0, openedAt)) >= cb.resetTimeout {
+			return atomic.CompareAndSwapInt32(&cb.state, breakerOpen, breakerHalfOpen)
+		}
+		return false
+	case breakerHalfOpen:
+		// A probe is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+// Success records a successful map call, closing the breaker and resetting the
+// failure run.
+func (cb *CircuitBreaker) Success() {
+	atomic.StoreInt32(&cb.consecutiveFails, 0)
+	atomic.StoreInt32(&cb.state, breakerClosed)
+}
+
+// Failure records a failed map call. A failed half-open probe re-opens the breaker
+// immediately; while closed, reaching the threshold of consecutive failures opens it.
+func (cb *CircuitBreaker) Failure() {
+	if atomic.LoadInt32(&cb.state) == breakerHalfOpen {
+		atomic.StoreInt64(&cb.openedAt, time.Now().UnixNano())
+		atomic.StoreInt32(&cb.state, breakerOpen)
+		return
+	}
+	fails := atomic.AddInt32(&cb.consecutiveFails, 1)
+	if int(fails) >= cb.failureThreshold {
+		atomic.StoreInt64(&cb.openedAt, time.Now().UnixNano())
+		atomic.StoreInt32(&cb.state, breakerOpen)
+	}
+}
+
+// StreamOption customizes a streaming run.
+type StreamOption func(*streamOptions)
+
+// streamOptions collects the optional knobs for a streaming run.
+type streamOptions struct {
+	breaker *CircuitBreaker
+	limiter *util.RateLimiter
+	buffers ChannelBuffers
+	timeout time.Duration
+}
+
+// ChannelBuffers sizes the internal channels of a streaming run. Zero fields take
+// the defaults: 1000 records, 1000 tasks, and workerCount*2 results. Larger buffers
+// trade memory for less blocking between the loader, workers, and reducer.
+type ChannelBuffers struct {
+	Record int // Capacity of the record channel fed by the file loader
+	Task   int // Capacity of the task channel feeding the workers
+	Result int // Capacity of the result channel feeding the reducer
+}
+
+// withDefaults fills zero fields with the documented defaults for a pool of
+// workerCount workers.
+func (b ChannelBuffers) withDefaults(workerCount int) ChannelBuffers {
+	if b.Record <= 0 {
+		b.Record = defaultRecordChanBuffer
+	}
+	if b.Task <= 0 {
+		b.Task = defaultTaskChanBuffer
+	}
+	if b.Result <= 0 {
+		b.Result = workerCount * 2
+		if b.Result <= 0 {
+			b.Result = 2
+		}
+	}
+	return b
+}
+
+// WithCircuitBreaker attaches a shared circuit breaker to the run: after threshold
+// consecutive map failures, records are failed fast without touching the database
+// until timeout elapses and a probe succeeds.
+func WithCircuitBreaker(threshold int, timeout time.Duration) StreamOption {
+	return func(o *streamOptions) { o.breaker = NewCircuitBreaker(threshold, timeout) }
+}
+
+// WithRateLimiter caps the aggregate insert rate across all workers, protecting
+// shared database instances during bulk loads. The limiter is shared: every worker
+// waits on it before each map call. A nil limiter disables limiting.
+func WithRateLimiter(limiter *util.RateLimiter) StreamOption {
+	return func(o *streamOptions) { o.limiter = limiter }
+}
+
+// WithChannelBuffers overrides the streaming run's channel capacities for memory vs.
+// throughput tuning; zero fields keep their defaults.
+func WithChannelBuffers(buffers ChannelBuffers) StreamOption {
+	return func(o *streamOptions) { o.buffers = buffers }
+}
+
+// WithBatchTimeout bounds each map call: a call that exceeds the timeout (lock
+// contention, runaway query) fails its batch so the reducer rolls it back, instead
+// of hanging a worker indefinitely. Zero disables the bound.
+func WithBatchTimeout(timeout time.Duration) StreamOption {
+	return func(o *streamOptions) { o.timeout = timeout }
+}
+
+// WorkerScaler decides how many workers a streaming run should have, given the
+// current worker count, the depth of the task queue, and recent throughput. It is
+// sampled periodically during the run; returning the current count leaves the pool
+// unchanged.
+type WorkerScaler interface {
+	Adjust(currentWorkers, queueDepth, processedPerSec int) int
+}
+
+// DefaultScaler halves the worker count when the task queue is nearly empty (the
+// workers are outpacing the loader) and doubles it when the queue is nearly full (the
+// loader is outpacing the workers), clamped to [Min, Max].
+type DefaultScaler struct {
+	Min int // Lower bound on workers; <= 0 means 1
+	Max int // Upper bound on workers; <= 0 means unbounded
+	// TaskQueueCapacity is the task channel capacity the thresholds are expressed
+	// against; <= 0 uses the default task buffer size. Keep it in sync with any
+	// WithChannelBuffers override so the percentages stay meaningful.
+	TaskQueueCapacity int
+}
+
+// Adjust implements WorkerScaler with queue-depth thresholds at 5% and 80% of the
+// task channel capacity.
+func (s DefaultScaler) Adjust(currentWorkers, queueDepth, processedPerSec int) int {
+	capacity := s.TaskQueueCapacity
+	if capacity <= 0 {
+		capacity = defaultTaskChanBuffer
+	}
+	target := currentWorkers
+	switch {
+	case float64(queueDepth) > float64(capacity)*0.8:
+		target = currentWorkers * 2
+	case float64(queueDepth) < float64(capacity)*0.05:
+		target = currentWorkers / 2
+	}
+	if s.Min > 0 && target < s.Min {
+		target = s.Min
+	}
+	if s.Max > 0 && target > s.Max {
+		target = s.Max
+	}
+	if target < 1 {
+		target = 1
+	}
+	return target
+}
+
+// logProgress periodically logs totals and throughput until done is closed, so
+// operators can see ingestion rate on long-running files.
+func logProgress(counter *util.Counter, done <-chan struct{}) {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			snapshot := counter.Snapshot()
+			logger.Info("Streaming progress",
+				zap.Int("succeeded", snapshot.Succeeded),
+				zap.Int("errors", snapshot.Errors),
+				zap.Float64("overallRecPerSec", counter.OverallRate()),
+				zap.Float64("windowRecPerSec", counter.WindowRate()))
+		}
+	}
+}
+
+// RecordIndexKey is a reserved record key carrying the record's index within the
+// source file. Stream functions that support checkpoint/resume set it on each record;
+// workers strip it before the map call and report the indexes they processed in
+// MapResult.RecordIndexes so the reducer knows exactly which records each commit
+// covered.
+const RecordIndexKey = "__record_index"
+
 // MapResult holds the outcome of a Map task.
 type MapResult struct {
-	BatchID int       // Identifier for the batch
-	Err     error     // Error encountered during processing (if any)
-	Tx      *sql.Tx   // The transaction associated with this batch
+	BatchID       int     // Identifier for the batch
+	Err           error   // Error encountered during processing (if any)
+	Tx            *sql.Tx // The transaction associated with this batch
+	RecordIndexes []int   // Source-file indexes of records mapped in this batch (when tracked)
 }
 
 // Task represents a unit of work to be processed.
@@ -23,53 +266,537 @@ type Task struct {
 // MapFunc defines the function signature for the map phase.
 type MapFunc func(tx *sql.Tx, tableName string, batch map[string]interface{}) error
 
+// StreamResult summarizes a streaming run: per-record totals, the raw per-worker
+// outcomes, and how long the whole run took.
+type StreamResult struct {
+	TotalProcessed int           // Records handed to workers (succeeded + failed)
+	TotalSucceeded int           // Records inserted successfully
+	TotalFailed    int           // Records that errored in the map phase
+	SkippedRecords int           // Records skipped by insert conditions or duplicate handling
+	WorkerResults  []MapResult   // Raw per-worker batch outcomes
+	Duration       time.Duration // Wall-clock duration of the run
+}
+
 // ReduceFunc defines the function signature for reduce phase.
-type ReduceFunc func(results []MapResult) error
+type ReduceFunc func(results []MapResult) (StreamResult, error)
+
+// ReduceStreamFunc handles a single MapResult as soon as its worker finishes,
+// committing or rolling back promptly instead of waiting for the whole run.
+type ReduceStreamFunc func(result MapResult) error
+
+// worker processes tasks from the taskChan and sends results to resultChan. Its
+// transaction is started with txOptions; a nil txOptions uses the database default
+// isolation level. Closing stop retires the worker after its current record; a nil
+// stop channel means the worker runs until taskChan is drained. The limiter, when
+// non-nil, is shared across all workers and paces map calls to the configured rate.
+// A positive timeout bounds each map call; a timed-out call abandons the batch so
+// the reducer rolls it back.
+func worker(taskChan <-chan map[string]interface{}, resultChan chan<- MapResult, mapFunc MapFunc, db *sql.DB, tableName string, txOptions *sql.TxOptions, batchID int, wg *sync.WaitGroup, counter *util.Counter, stop <-chan struct{}, breaker *CircuitBreaker, limiter *util.RateLimiter, timeout time.Duration) {
+	defer wg.Done()
+	util.Metrics.WorkerStarted()
+	defer util.Metrics.WorkerFinished()
+	tx, err := db.BeginTx(context.Background(), txOptions) // Start a transaction
+	if err != nil {
+		resultChan <- MapResult{BatchID: batchID, Err: err, Tx: nil}
+		return
+	}
+
+	// Source-file indexes of the records this batch mapped successfully, reported to
+	// the reducer for checkpointing
+	var recordIndexes []int
+	defer func() {
+		resultChan <- MapResult{BatchID: batchID, Err: err, Tx: tx, RecordIndexes: recordIndexes}
+	}()
+
+	for {
+		select {
+		case <-stop:
+			// Retired by the scaler; the batch so far is handed to the reducer as-is
+			return
+		case batch, ok := <-taskChan:
+			if !ok {
+				return
+			}
+			// Strip the reserved index key before the map call so it is never
+			// treated as a column
+			recordIndex := -1
+			if v, ok := batch[RecordIndexKey]; ok {
+				if i, ok := v.(int); ok {
+					recordIndex = i
+				}
+				delete(batch, RecordIndexKey)
+			}
+			// Fail fast while the breaker is open rather than hammering a database
+			// that is already down
+			if breaker != nil && !breaker.Allow() {
+				counter.IncrementErrors(1)
+				continue
+			}
+			// Pace inserts so bulk loads cannot saturate a shared database; a nil
+			// limiter waits for nothing
+			limiter.Wait()
+			// Execute the Map function within the transaction
+			err = runMapFuncWithTimeout(mapFunc, tx, tableName, batch, timeout)
+			if err != nil {
+				if breaker != nil {
+					breaker.Failure()
+				}
+				counter.IncrementErrors(1)
+				// A timed-out call may still be holding the transaction; abandon
+				// the batch (the reducer rolls it back) rather than risk using the
+				// transaction concurrently
+				if errors.Is(err, ErrBatchTimeout) {
+					return
+				}
+				continue
+			}
+			if breaker != nil {
+				breaker.Success()
+			}
+			if recordIndex >= 0 {
+				recordIndexes = append(recordIndexes, recordIndex)
+			}
+			counter.IncrementSucceeded(1)
+		}
+	}
+}
+
+// runMapFunc executes the Map function, converting a panic (e.g. from reflection on an
+// unexpected value) into an ordinary error so one bad record cannot take down the whole
+// process and the remaining workers can finish.
+func runMapFunc(mapFunc MapFunc, tx *sql.Tx, tableName string, batch map[string]interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("map function panicked: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return mapFunc(tx, tableName, batch)
+}
+
+// ErrBatchTimeout marks a batch abandoned because one of its map calls exceeded the
+// configured per-batch timeout. Match it with errors.Is.
+var ErrBatchTimeout = errors.New("map call exceeded the batch timeout")
+
+// runMapFuncWithTimeout runs the Map function under a deadline. On timeout it
+// returns ErrBatchTimeout immediately; the abandoned call keeps running in its
+// goroutine until the driver gives up, so the caller must stop using the
+// transaction and leave it to the reducer to roll back.
+func runMapFuncWithTimeout(mapFunc MapFunc, tx *sql.Tx, tableName string, batch map[string]interface{}, timeout time.Duration) error {
+	if timeout <= 0 {
+		return runMapFunc(mapFunc, tx, tableName, batch)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runMapFunc(mapFunc, tx, tableName, batch)
+	}()
 
-// worker processes tasks from the taskChan and sends results to resultChan.
-func worker(taskChan <-chan map[string]interface{}, resultChan chan<- MapResult, mapFunc MapFunc, db *sql.DB, tableName string, batchID int, wg *sync.WaitGroup, counter *util.Counter) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("%w (%s)", ErrBatchTimeout, timeout)
+	}
+}
+
+// BatchMapFunc is the map-phase signature for true batching: one call receives a
+// slice of records and is expected to write them in a single statement (e.g. a
+// multi-row INSERT). Use it via MapReduceStreamingBatched when insert throughput
+// matters more than per-record error isolation; the per-record MapFunc remains
+// the right choice when one bad record must not fail its neighbors.
+type BatchMapFunc func(tx *sql.Tx, tableName string, batch []map[string]interface{}) error
+
+// batchWorker is the worker counterpart for BatchMapFunc: each task is a slice
+// of records mapped in one call, and counters move by the batch size. The pool
+// is fixed (no scaler stop channel); breaker, limiter, and timeout behave as in
+// worker, with the limiter paced once per statement rather than per record.
+func batchWorker(taskChan <-chan []map[string]interface{}, resultChan chan<- MapResult, mapFunc BatchMapFunc, db *sql.DB, tableName string, txOptions *sql.TxOptions, batchID int, wg *sync.WaitGroup, counter *util.Counter, breaker *CircuitBreaker, limiter *util.RateLimiter, timeout time.Duration) {
 	defer wg.Done()
-	tx, err := db.Begin() // Start a transaction
+	util.Metrics.WorkerStarted()
+	defer util.Metrics.WorkerFinished()
+	tx, err := db.BeginTx(context.Background(), txOptions)
 	if err != nil {
 		resultChan <- MapResult{BatchID: batchID, Err: err, Tx: nil}
 		return
 	}
 
+	var recordIndexes []int
 	defer func() {
-		resultChan <- MapResult{BatchID: batchID, Err: err, Tx: tx}
+		resultChan <- MapResult{BatchID: batchID, Err: err, Tx: tx, RecordIndexes: recordIndexes}
 	}()
 
 	for batch := range taskChan {
-		// Execute the Map function within the transaction
-		err = mapFunc(tx, tableName, batch)
+		// Strip the reserved index key from every record before the map call
+		indexes := make([]int, 0, len(batch))
+		for _, record := range batch {
+			if v, ok := record[RecordIndexKey]; ok {
+				if i, ok := v.(int); ok {
+					indexes = append(indexes, i)
+				}
+				delete(record, RecordIndexKey)
+			}
+		}
+		if breaker != nil && !breaker.Allow() {
+			counter.IncrementErrors(len(batch))
+			continue
+		}
+		limiter.Wait()
+		err = runBatchMapFuncWithTimeout(mapFunc, tx, tableName, batch, timeout)
 		if err != nil {
-			counter.IncrementErrors(1)
+			if breaker != nil {
+				breaker.Failure()
+			}
+			counter.IncrementErrors(len(batch))
+			// A timed-out call may still be holding the transaction; abandon the
+			// batch (the reducer rolls it back) rather than risk using the
+			// transaction concurrently
+			if errors.Is(err, ErrBatchTimeout) {
+				return
+			}
 			continue
 		}
-		counter.IncrementSucceeded(1)
+		if breaker != nil {
+			breaker.Success()
+		}
+		recordIndexes = append(recordIndexes, indexes...)
+		counter.IncrementSucceeded(len(batch))
 	}
 }
 
-// MapReduceStreaming orchestrates the Map and Reduce phases with streaming.
+// runBatchMapFunc executes the batch Map function with the same panic-to-error
+// conversion as runMapFunc.
+func runBatchMapFunc(mapFunc BatchMapFunc, tx *sql.Tx, tableName string, batch []map[string]interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("map function panicked: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return mapFunc(tx, tableName, batch)
+}
+
+// runBatchMapFuncWithTimeout runs the batch Map function under a deadline, with
+// the same abandoned-call semantics as runMapFuncWithTimeout.
+func runBatchMapFuncWithTimeout(mapFunc BatchMapFunc, tx *sql.Tx, tableName string, batch []map[string]interface{}, timeout time.Duration) error {
+	if timeout <= 0 {
+		return runBatchMapFunc(mapFunc, tx, tableName, batch)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runBatchMapFunc(mapFunc, tx, tableName, batch)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("%w (%s)", ErrBatchTimeout, timeout)
+	}
+}
+
+// MapReduceStreaming orchestrates the Map and Reduce phases with streaming. The
+// returned StreamResult carries record totals, per-worker outcomes, and the run
+// duration so callers can report more than pass/fail.
 func MapReduceStreaming(
 	fileLoader func(chan map[string]interface{}) error, // Function to stream records from a file
 	mapFunc MapFunc,                         // Function to handle Map phase
 	reduceFunc ReduceFunc,                   // Function to handle Reduce phase
 	db *sql.DB,                              // Database connection
 	tableName string,                        // Database table name
-	workerCount int,                         // Number of workers
+	txOptions *sql.TxOptions,                // Transaction options for workers (nil = database default)
+	workerCount int,                         // Initial number of workers
+	scaler WorkerScaler,                     // Optional dynamic worker scaling (nil = fixed pool)
 	counter *util.Counter,
-) error {
-	// Channels for streaming records and task batches
-	recordChan := make(chan map[string]interface{}, 20)
-	taskChan := make(chan map[string]interface{}, 20)
-	resultChan := make(chan MapResult, 20)
+	opts ...StreamOption,                    // Optional run behavior (e.g. WithCircuitBreaker)
+) (StreamResult, error) {
+	start := time.Now()
+	var options streamOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	// Channels for streaming records and task batches, sized per the configured
+	// (or default) buffer capacities
+	buffers := options.buffers.withDefaults(workerCount)
+	recordChan := make(chan map[string]interface{}, buffers.Record)
+	taskChan := make(chan map[string]interface{}, buffers.Task)
+	resultChan := make(chan MapResult, buffers.Result)
 	var wg sync.WaitGroup
 
+	// Periodic progress/throughput logging for the duration of the run
+	counter.StartTiming()
+	progressDone := make(chan struct{})
+	defer close(progressDone)
+	go logProgress(counter, progressDone)
+
+	// Worker pool bookkeeping: each worker gets a stop channel so the scaler can
+	// retire individual workers without touching the others. Once draining is set
+	// the pool refuses to grow: a worker added after wg.Wait has begun would race
+	// the WaitGroup and send its result on the closed result channel.
+	var workersMu sync.Mutex
+	var stops []chan struct{}
+	draining := false
+	nextBatchID := 0
+	startWorker := func() {
+		if draining {
+			return
+		}
+		stop := make(chan struct{})
+		stops = append(stops, stop)
+		wg.Add(1)
+		go worker(taskChan, resultChan, mapFunc, db, tableName, txOptions, nextBatchID, &wg, counter, stop, options.breaker, options.limiter, options.timeout)
+		nextBatchID++
+	}
+
+	// Start workers
+	workersMu.Lock()
+	for i := 0; i < workerCount; i++ {
+		startWorker()
+	}
+	workersMu.Unlock()
+
+	// With a scaler attached, periodically sample the task queue depth and grow or
+	// shrink the pool within the scaler's bounds. The scaler stops on scalerStop,
+	// which is closed before the pool is allowed to drain below.
+	scalerStop := make(chan struct{})
+	if scaler != nil {
+		interval := scaleInterval
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-scalerStop:
+					return
+				case <-ticker.C:
+					workersMu.Lock()
+					current := len(stops)
+					target := scaler.Adjust(current, len(taskChan), int(counter.WindowRate()))
+					if target > current {
+						for i := current; i < target; i++ {
+							startWorker()
+						}
+						logger.Info("Scaler grew worker pool",
+							zap.Int("from", current),
+							zap.Int("to", target),
+							zap.Int("queueDepth", len(taskChan)))
+					} else if target < current {
+						for i := current; i > target; i-- {
+							stop := stops[len(stops)-1]
+							stops = stops[:len(stops)-1]
+							close(stop)
+						}
+						logger.Info("Scaler shrank worker pool",
+							zap.Int("from", current),
+							zap.Int("to", target),
+							zap.Int("queueDepth", len(taskChan)))
+					}
+					workersMu.Unlock()
+				}
+			}
+		}()
+	}
+
+	// Stream records from the file. Once the last record is dispatched, the scaler
+	// is retired and the pool marked as draining *before* taskChan closes: workers
+	// can only finish after taskChan closes, so no worker can be added concurrently
+	// with the wg.Wait below or send on the closed result channel.
+	go func() {
+		for record := range recordChan {
+			taskChan <- record
+		}
+		close(scalerStop)
+		workersMu.Lock()
+		draining = true
+		workersMu.Unlock()
+		close(taskChan)
+	}()
+
+	// Start file loading (streaming records). The channel is closed exactly once,
+	// success or failure; closing again on the error path would panic the process.
+	go func() {
+		defer close(recordChan)
+		if err := fileLoader(recordChan); err != nil {
+			logger.Error("File loader failed", zap.Error(err))
+		}
+	}()
+
+	// Wait for workers to finish
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	// Collect results
+	var results []MapResult
+	for result := range resultChan {
+		results = append(results, result)
+	}
+
+	// Perform Reduce phase, then fill in the run-level totals and timing
+	streamResult, err := reduceFunc(results)
+	if streamResult.WorkerResults == nil {
+		streamResult.WorkerResults = results
+	}
+	snapshot := counter.Snapshot()
+	streamResult.TotalSucceeded = snapshot.Succeeded
+	streamResult.TotalFailed = snapshot.Errors
+	streamResult.TotalProcessed = snapshot.Succeeded + snapshot.Errors
+	streamResult.SkippedRecords = snapshot.Skipped
+	streamResult.Duration = time.Since(start)
+	return streamResult, err
+}
+
+// MapReduceStreamingBatched orchestrates the Map and Reduce phases with true
+// batching: records are accumulated into slices of batchSize and each slice is
+// handed to batchMapFunc in a single call, so the map function can issue one
+// multi-row INSERT per batch. The final partial batch is dispatched as-is. The
+// pool is fixed; prefer MapReduceStreaming when per-record error isolation or
+// dynamic scaling is needed.
+func MapReduceStreamingBatched(
+	fileLoader func(chan map[string]interface{}) error, // Function to stream records from a file
+	batchMapFunc BatchMapFunc,                          // Function to handle Map phase, one call per batch
+	reduceFunc ReduceFunc,                              // Function to handle Reduce phase
+	db *sql.DB,                                         // Database connection
+	tableName string,                                   // Database table name
+	txOptions *sql.TxOptions,                           // Transaction options for workers (nil = database default)
+	workerCount int,                                    // Number of workers
+	batchSize int,                                      // Records per map call (<= 0 falls back to 100)
+	counter *util.Counter,
+	opts ...StreamOption, // Optional run behavior (e.g. WithCircuitBreaker)
+) (StreamResult, error) {
+	start := time.Now()
+	var options streamOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	// Channels for streaming records and task batches, sized per the configured
+	// (or default) buffer capacities
+	buffers := options.buffers.withDefaults(workerCount)
+	recordChan := make(chan map[string]interface{}, buffers.Record)
+	taskChan := make(chan []map[string]interface{}, buffers.Task)
+	resultChan := make(chan MapResult, buffers.Result)
+	var wg sync.WaitGroup
+
+	// Periodic progress/throughput logging for the duration of the run
+	counter.StartTiming()
+	progressDone := make(chan struct{})
+	defer close(progressDone)
+	go logProgress(counter, progressDone)
+
 	// Start workers
 	for i := 0; i < workerCount; i++ {
 		wg.Add(1)
-		go worker(taskChan, resultChan, mapFunc, db, tableName, i, &wg, counter)
+		go batchWorker(taskChan, resultChan, batchMapFunc, db, tableName, txOptions, i, &wg, counter, options.breaker, options.limiter, options.timeout)
+	}
+
+	// Accumulate streamed records into batches, flushing the partial final batch
+	go func() {
+		defer close(taskChan)
+		batch := make([]map[string]interface{}, 0, batchSize)
+		for record := range recordChan {
+			batch = append(batch, record)
+			if len(batch) == batchSize {
+				taskChan <- batch
+				batch = make([]map[string]interface{}, 0, batchSize)
+			}
+		}
+		if len(batch) > 0 {
+			taskChan <- batch
+		}
+	}()
+
+	// Start file loading (streaming records). The channel is closed exactly once,
+	// success or failure; closing again on the error path would panic the process.
+	go func() {
+		defer close(recordChan)
+		if err := fileLoader(recordChan); err != nil {
+			logger.Error("File loader failed", zap.Error(err))
+		}
+	}()
+
+	// Wait for workers to finish
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	// Collect results
+	var results []MapResult
+	for result := range resultChan {
+		results = append(results, result)
+	}
+
+	// Perform Reduce phase, then fill in the run-level totals and timing
+	streamResult, err := reduceFunc(results)
+	if streamResult.WorkerResults == nil {
+		streamResult.WorkerResults = results
+	}
+	snapshot := counter.Snapshot()
+	streamResult.TotalSucceeded = snapshot.Succeeded
+	streamResult.TotalFailed = snapshot.Errors
+	streamResult.TotalProcessed = snapshot.Succeeded + snapshot.Errors
+	streamResult.SkippedRecords = snapshot.Skipped
+	streamResult.Duration = time.Since(start)
+	return streamResult, err
+}
+
+// MapReduceStreamingWindowed orchestrates the Map and Reduce phases while keeping at most
+// maxOpenTx worker transactions open at once. Workers acquire a transaction slot before
+// beginning, which applies backpressure once the window is full; the reducer processes
+// each result as soon as its worker finishes, committing or rolling back promptly to free
+// the slot. This bounds resource use on huge runs while preserving per-batch atomicity.
+func MapReduceStreamingWindowed(
+	fileLoader func(chan map[string]interface{}) error, // Function to stream records from a file
+	mapFunc MapFunc,                                    // Function to handle Map phase
+	reduceStream ReduceStreamFunc,                      // Function invoked per completed batch
+	db *sql.DB,                                         // Database connection
+	tableName string,                                   // Database table name
+	txOptions *sql.TxOptions,                           // Transaction options for workers (nil = database default)
+	workerCount int,                                    // Number of workers
+	maxOpenTx int,                                      // Maximum concurrently open transactions
+	counter *util.Counter,
+	opts ...StreamOption, // Optional run behavior (e.g. WithRateLimiter)
+) (StreamResult, error) {
+	var options streamOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	start := time.Now()
+	// Clamp the window: it can never usefully exceed the worker count
+	if maxOpenTx <= 0 || maxOpenTx > workerCount {
+		maxOpenTx = workerCount
+	}
+
+	// Channels for streaming records and task batches, sized per the configured
+	// (or default) buffer capacities
+	buffers := options.buffers.withDefaults(workerCount)
+	recordChan := make(chan map[string]interface{}, buffers.Record)
+	taskChan := make(chan map[string]interface{}, buffers.Task)
+	resultChan := make(chan MapResult, buffers.Result)
+	txSlots := make(chan struct{}, maxOpenTx)
+	var wg sync.WaitGroup
+
+	// Periodic progress/throughput logging for the duration of the run
+	counter.StartTiming()
+	progressDone := make(chan struct{})
+	defer close(progressDone)
+	go logProgress(counter, progressDone)
+
+	// Start workers; each acquires a transaction slot before beginning
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func(batchID int) {
+			txSlots <- struct{}{}
+			worker(taskChan, resultChan, mapFunc, db, tableName, txOptions, batchID, &wg, counter, nil, options.breaker, options.limiter, options.timeout)
+		}(i)
 	}
 
 	// Stream records from the file
@@ -80,12 +807,13 @@ func MapReduceStreaming(
 		}
 	}()
 
-	// Start file loading (streaming records)
+	// Start file loading (streaming records). The channel is closed exactly once,
+	// success or failure; closing again on the error path would panic the process.
 	go func() {
+		defer close(recordChan)
 		if err := fileLoader(recordChan); err != nil {
-			close(recordChan) // Ensure recordChan is closed if there's an error
+			logger.Error("File loader failed", zap.Error(err))
 		}
-		close(recordChan)
 	}()
 
 	// Wait for workers to finish
@@ -94,14 +822,31 @@ func MapReduceStreaming(
 		close(resultChan)
 	}()
 
-	// Collect results
+	// Reduce each result as it arrives, releasing the transaction slot afterwards
 	var results []MapResult
+	var reduceErrs []error
 	for result := range resultChan {
 		results = append(results, result)
+		if err := reduceStream(result); err != nil {
+			reduceErrs = append(reduceErrs, err)
+		}
+		<-txSlots
 	}
 
-	// Perform Reduce phase
-	return reduceFunc(results)
+	snapshot := counter.Snapshot()
+	streamResult := StreamResult{
+		TotalProcessed: snapshot.Succeeded + snapshot.Errors,
+		TotalSucceeded: snapshot.Succeeded,
+		TotalFailed:    snapshot.Errors,
+		SkippedRecords: snapshot.Skipped,
+		WorkerResults:  results,
+		Duration:       time.Since(start),
+	}
+
+	if len(reduceErrs) > 0 {
+		return streamResult, fmt.Errorf("streaming reduce completed with %d failed batch(es): %v", len(reduceErrs), reduceErrs[0])
+	}
+	return streamResult, nil
 }
 
 // MapReduce orchestrates the Map and Reduce phases.
@@ -0,0 +1,284 @@
+package mapreduce
+
+import (
+	"data-ingestor/util"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// txTracker counts concurrently open stub transactions across connections, so
+// tests can assert the transaction window is honored.
+type txTracker struct {
+	mu      sync.Mutex
+	open    int
+	maxOpen int
+}
+
+func (tr *txTracker) begin() {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.open++
+	if tr.open > tr.maxOpen {
+		tr.maxOpen = tr.open
+	}
+}
+
+func (tr *txTracker) end() {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.open--
+}
+
+func (tr *txTracker) max() int {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.maxOpen
+}
+
+// stubDriver is a minimal database/sql driver whose transactions only feed the
+// shared tracker; the workers under test never execute real SQL themselves.
+type stubDriver struct{ tracker *txTracker }
+
+func (d *stubDriver) Open(string) (driver.Conn, error) {
+	return &stubConn{tracker: d.tracker}, nil
+}
+
+type stubConn struct{ tracker *txTracker }
+
+func (c *stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported by the stub driver")
+}
+
+func (c *stubConn) Close() error { return nil }
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	c.tracker.begin()
+	return stubTx{tracker: c.tracker}, nil
+}
+
+type stubTx struct{ tracker *txTracker }
+
+func (tx stubTx) Commit() error {
+	tx.tracker.end()
+	return nil
+}
+
+func (tx stubTx) Rollback() error {
+	tx.tracker.end()
+	return nil
+}
+
+// stubDriverSeq makes registered driver names unique, since database/sql
+// panics on duplicate registration.
+var stubDriverSeq atomic.Uint64
+
+// openStubDB registers a stub driver around tracker and opens a *sql.DB on it.
+func openStubDB(t *testing.T, tracker *txTracker) *sql.DB {
+	t.Helper()
+	name := fmt.Sprintf("mapreduce-stub-%d", stubDriverSeq.Add(1))
+	sql.Register(name, &stubDriver{tracker: tracker})
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("opening stub database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// streamRecords returns a fileLoader that streams count records into the channel.
+func streamRecords(count int) func(chan map[string]interface{}) error {
+	return func(recordChan chan map[string]interface{}) error {
+		for i := 0; i < count; i++ {
+			recordChan <- map[string]interface{}{"i": i}
+		}
+		return nil
+	}
+}
+
+func TestMapReduceStreamingRecoversFromPanickingMapFunc(t *testing.T) {
+	db := openStubDB(t, &txTracker{})
+	counter := &util.Counter{}
+
+	mapFunc := func(tx *sql.Tx, tableName string, record map[string]interface{}) error {
+		if record["i"].(int) == 1 {
+			panic("record is not a struct")
+		}
+		return nil
+	}
+	reduceFunc := func(results []MapResult) (StreamResult, error) {
+		for _, result := range results {
+			if result.Tx != nil {
+				result.Tx.Rollback()
+			}
+		}
+		return StreamResult{}, nil
+	}
+
+	result, err := MapReduceStreaming(streamRecords(3), mapFunc, reduceFunc, db, "loads", nil, 2, nil, counter)
+	if err != nil {
+		t.Fatalf("MapReduceStreaming: %v", err)
+	}
+	if result.TotalFailed != 1 {
+		t.Errorf("TotalFailed = %d, want 1 (the panicking record)", result.TotalFailed)
+	}
+	if result.TotalSucceeded != 2 {
+		t.Errorf("TotalSucceeded = %d, want 2", result.TotalSucceeded)
+	}
+}
+
+func TestMapReduceStreamingWindowedBoundsOpenTransactions(t *testing.T) {
+	tracker := &txTracker{}
+	db := openStubDB(t, tracker)
+	counter := &util.Counter{}
+
+	const (
+		recordCount = 40
+		workerCount = 8
+		maxOpenTx   = 3
+	)
+
+	// A slow map call keeps transactions open long enough that an unbounded
+	// pool would overlap more than maxOpenTx of them
+	mapFunc := func(tx *sql.Tx, tableName string, record map[string]interface{}) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	}
+	reduceStream := func(result MapResult) error {
+		if result.Tx != nil {
+			return result.Tx.Commit()
+		}
+		return result.Err
+	}
+
+	result, err := MapReduceStreamingWindowed(streamRecords(recordCount), mapFunc, reduceStream, db, "loads", nil, workerCount, maxOpenTx, counter)
+	if err != nil {
+		t.Fatalf("MapReduceStreamingWindowed: %v", err)
+	}
+	if result.TotalSucceeded != recordCount {
+		t.Errorf("TotalSucceeded = %d, want %d", result.TotalSucceeded, recordCount)
+	}
+	if max := tracker.max(); max > maxOpenTx {
+		t.Errorf("open transactions peaked at %d, want at most %d", max, maxOpenTx)
+	}
+}
+
+func TestDefaultScalerAdjust(t *testing.T) {
+	scaler := DefaultScaler{Min: 2, Max: 16, TaskQueueCapacity: 100}
+
+	if got := scaler.Adjust(4, 90, 0); got != 8 {
+		t.Errorf("Adjust with a nearly full queue = %d, want 8 (doubled)", got)
+	}
+	if got := scaler.Adjust(8, 2, 0); got != 4 {
+		t.Errorf("Adjust with a nearly empty queue = %d, want 4 (halved)", got)
+	}
+	if got := scaler.Adjust(4, 50, 0); got != 4 {
+		t.Errorf("Adjust with a mid-depth queue = %d, want the pool unchanged", got)
+	}
+	if got := scaler.Adjust(16, 90, 0); got != 16 {
+		t.Errorf("Adjust at Max = %d, want the pool clamped to 16", got)
+	}
+	if got := scaler.Adjust(4, 0, 0); got != 2 {
+		t.Errorf("Adjust at Min = %d, want the pool clamped to 2", got)
+	}
+}
+
+// growScaler always asks for one more worker, forcing pool growth on every
+// sample so scaling races with the pool draining at the end of the run.
+type growScaler struct{ max int }
+
+func (s growScaler) Adjust(currentWorkers, queueDepth, processedPerSec int) int {
+	if currentWorkers >= s.max {
+		return currentWorkers
+	}
+	return currentWorkers + 1
+}
+
+func TestMapReduceStreamingScalerDoesNotRaceShutdown(t *testing.T) {
+	// Sample aggressively so scaling keeps firing while the last records drain
+	oldInterval := scaleInterval
+	scaleInterval = time.Millisecond
+	t.Cleanup(func() { scaleInterval = oldInterval })
+
+	db := openStubDB(t, &txTracker{})
+	counter := &util.Counter{}
+
+	const recordCount = 200
+	mapFunc := func(tx *sql.Tx, tableName string, record map[string]interface{}) error {
+		time.Sleep(100 * time.Microsecond)
+		return nil
+	}
+	reduceFunc := func(results []MapResult) (StreamResult, error) {
+		for _, result := range results {
+			if result.Tx != nil {
+				result.Tx.Rollback()
+			}
+		}
+		return StreamResult{}, nil
+	}
+
+	result, err := MapReduceStreaming(streamRecords(recordCount), mapFunc, reduceFunc, db, "loads", nil, 2, growScaler{max: 16}, counter)
+	if err != nil {
+		t.Fatalf("MapReduceStreaming: %v", err)
+	}
+	if result.TotalSucceeded != recordCount {
+		t.Errorf("TotalSucceeded = %d, want %d", result.TotalSucceeded, recordCount)
+	}
+}
+
+func TestRunMapFuncConvertsPanicToError(t *testing.T) {
+	mapFunc := func(tx *sql.Tx, tableName string, record map[string]interface{}) error {
+		panic("reflection on an unexpected value")
+	}
+	err := runMapFunc(mapFunc, nil, "loads", map[string]interface{}{})
+	if err == nil {
+		t.Fatal("expected the panic to surface as an error")
+	}
+	if !strings.Contains(err.Error(), "map function panicked") {
+		t.Errorf("error = %q, want it to name the panic", err)
+	}
+}
+
+func TestMapReduceStreamingBatchedDispatchesPartialFinalBatch(t *testing.T) {
+	db := openStubDB(t, &txTracker{})
+	counter := &util.Counter{}
+
+	// One worker so batches arrive in stream order; 25 records at a batch
+	// size of 10 must yield 10, 10, and a final partial 5
+	var mu sync.Mutex
+	var batchSizes []int
+	batchMapFunc := func(tx *sql.Tx, tableName string, batch []map[string]interface{}) error {
+		mu.Lock()
+		batchSizes = append(batchSizes, len(batch))
+		mu.Unlock()
+		return nil
+	}
+	reduceFunc := func(results []MapResult) (StreamResult, error) {
+		for _, result := range results {
+			if result.Tx != nil {
+				result.Tx.Commit()
+			}
+		}
+		return StreamResult{}, nil
+	}
+
+	result, err := MapReduceStreamingBatched(streamRecords(25), batchMapFunc, reduceFunc, db, "loads", nil, 1, 10, counter)
+	if err != nil {
+		t.Fatalf("MapReduceStreamingBatched: %v", err)
+	}
+	if result.TotalSucceeded != 25 {
+		t.Errorf("TotalSucceeded = %d, want 25 (no record dropped from the final partial batch)", result.TotalSucceeded)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []int{10, 10, 5}; fmt.Sprint(batchSizes) != fmt.Sprint(want) {
+		t.Errorf("batch sizes = %v, want %v", batchSizes, want)
+	}
+}
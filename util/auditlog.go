@@ -0,0 +1,91 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AuditEntry is a single per-file outcome written to the audit log.
+type AuditEntry struct {
+	Timestamp        string `json:"timestamp"`
+	InputFile        string `json:"inputFile"`
+	ModelName        string `json:"modelName"`
+	TableName        string `json:"tableName"`
+	RecordsSucceeded int    `json:"recordsSucceeded"`
+	RecordsErrored   int    `json:"recordsErrored"`
+	Status           string `json:"status"`
+	Error            string `json:"error,omitempty"`
+}
+
+// AuditLogger appends per-file processing outcomes to a JSON Lines file.
+// The file is append-only and rotated daily by suffixing the configured base
+// path with the current date (e.g. audit.jsonl.2024-06-15).
+type AuditLogger struct {
+	mu       sync.Mutex
+	basePath string
+	Logger   *zap.Logger
+}
+
+// NewAuditLogger creates an AuditLogger writing to the given base path.
+// An empty basePath yields a disabled logger whose methods are no-ops.
+func NewAuditLogger(basePath string, logger *zap.Logger) *AuditLogger {
+	return &AuditLogger{basePath: basePath, Logger: logger}
+}
+
+// LogCompletion appends an audit entry describing the outcome of one processed file.
+// Failures to write the audit log are logged but never fail the run itself.
+func (a *AuditLogger) LogCompletion(inputFile, modelName, tableName string, counter *Counter, runErr error) {
+	if a == nil || a.basePath == "" {
+		return
+	}
+
+	entry := AuditEntry{
+		Timestamp:        time.Now().UTC().Format(time.RFC3339),
+		InputFile:        inputFile,
+		ModelName:        modelName,
+		TableName:        tableName,
+		RecordsSucceeded: counter.GetSucceeded(),
+		RecordsErrored:   counter.GetErrors(),
+		Status:           "success",
+	}
+	if runErr != nil {
+		entry.Status = "failure"
+		entry.Error = runErr.Error()
+	}
+
+	if err := a.append(entry); err != nil {
+		a.Logger.Error("Failed to write audit log entry",
+			zap.String("auditLogFile", a.basePath),
+			zap.Error(err))
+	}
+}
+
+// append serializes the entry and appends it as one line to today's audit file.
+func (a *AuditLogger) append(entry AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Daily rotation: one file per calendar day based on a date suffix
+	path := fmt.Sprintf("%s.%s", a.basePath, time.Now().UTC().Format("2006-01-02"))
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log file: %w", err)
+	}
+	defer file.Close()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package util
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultChecksumSidecars are the sidecar extensions probed when none are configured,
+// matching the formats our transfer process writes (data.xml.sha256, data.xml.md5).
+var defaultChecksumSidecars = []string{".sha256", ".md5"}
+
+// ErrChecksumMismatch reports a file whose computed digest does not match the expected
+// one, carrying both digests so callers can log or match on it with errors.As.
+type ErrChecksumMismatch struct {
+	Algorithm string // Hash algorithm used (e.g., "sha256")
+	Expected  string // Digest the sidecar (or caller) claimed
+	Actual    string // Digest computed from the file contents
+}
+
+func (e *ErrChecksumMismatch) Error() string {
+	return fmt.Sprintf("%s checksum mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+// ComputeFileChecksum computes the hex digest of a file using the named algorithm
+// ("md5", "sha256", or "sha512"), streaming the contents so large files are not read
+// into memory.
+//
+// Parameters:
+//   - filePath: Path to the file to hash.
+//   - algorithm: Hash algorithm name.
+//
+// Returns:
+//   - string: The lowercase hex digest.
+//   - error: An error if the algorithm is unknown or the file cannot be read.
+func ComputeFileChecksum(filePath, algorithm string) (string, error) {
+	var h hash.Hash
+	switch strings.ToLower(algorithm) {
+	case "md5":
+		h = md5.New()
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported checksum algorithm %q (expected md5, sha256, or sha512)", algorithm)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file for checksum: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("failed to read file for checksum: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyChecksumSidecar looks for a checksum sidecar next to filePath (e.g.
+// data.xml.sha256) and verifies the file against it. Sidecar contents may be either a
+// bare digest or the common "digest  filename" format; only the first token is used.
+//
+// Parameters:
+//   - filePath: Path to the data file being verified.
+//   - sidecarExts: Sidecar extensions to probe in order; nil/empty uses .sha256 then .md5.
+//
+// Returns:
+//   - string: The verified digest when a sidecar was found and matched.
+//   - string: The algorithm that was used (derived from the sidecar extension).
+//   - bool: Whether a sidecar was found at all.
+//   - error: An *ErrChecksumMismatch on digest mismatch, or any read failure.
+func VerifyChecksumSidecar(filePath string, sidecarExts []string) (string, string, bool, error) {
+	if len(sidecarExts) == 0 {
+		sidecarExts = defaultChecksumSidecars
+	}
+
+	for _, ext := range sidecarExts {
+		sidecarPath := filePath + ext
+		contents, err := os.ReadFile(sidecarPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", "", true, fmt.Errorf("failed to read checksum sidecar %s: %w", sidecarPath, err)
+		}
+
+		algorithm := strings.ToLower(strings.TrimPrefix(ext, "."))
+		fields := strings.Fields(string(contents))
+		if len(fields) == 0 {
+			return "", algorithm, true, fmt.Errorf("checksum sidecar %s is empty", sidecarPath)
+		}
+		expected := strings.ToLower(fields[0])
+
+		actual, err := ComputeFileChecksum(filePath, algorithm)
+		if err != nil {
+			return "", algorithm, true, err
+		}
+		if actual != expected {
+			return "", algorithm, true, &ErrChecksumMismatch{Algorithm: algorithm, Expected: expected, Actual: actual}
+		}
+		return actual, algorithm, true, nil
+	}
+	return "", "", false, nil
+}
+
+// WriteChecksumSidecar writes a digest next to filePath using the algorithm name as
+// the extension (e.g. archived/data.xml.sha256), so downstream consumers can verify
+// the archived copy the same way we verified the input.
+func WriteChecksumSidecar(filePath, digest, algorithm string) error {
+	sidecarPath := filePath + "." + strings.ToLower(algorithm)
+	contents := fmt.Sprintf("%s  %s\n", digest, filepath.Base(filePath))
+	if err := os.WriteFile(sidecarPath, []byte(contents), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum sidecar %s: %w", sidecarPath, err)
+	}
+	return nil
+}
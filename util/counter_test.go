@@ -0,0 +1,65 @@
+package util
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCounterSnapshotIsConsistentUnderConcurrency(t *testing.T) {
+	counter := &Counter{}
+	const goroutines = 8
+	const perGoroutine = 1000
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	// Snapshot continuously while increments hammer the counter; succeeded and
+	// errors are always bumped together, so a consistent snapshot never shows
+	// them out of step by more than the number of writers mid-update.
+	go func() {
+		defer close(done)
+		for i := 0; i < 10000; i++ {
+			snap := counter.Snapshot()
+			diff := snap.Succeeded - snap.Errors
+			if diff < 0 || diff > goroutines {
+				t.Errorf("inconsistent snapshot: succeeded=%d errors=%d", snap.Succeeded, snap.Errors)
+				return
+			}
+		}
+	}()
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				counter.IncrementSucceeded(1)
+				counter.IncrementErrors(1)
+			}
+		}()
+	}
+	wg.Wait()
+	<-done
+
+	if got := counter.GetSucceeded(); got != goroutines*perGoroutine {
+		t.Errorf("GetSucceeded = %d, want %d", got, goroutines*perGoroutine)
+	}
+	if got := counter.GetErrors(); got != goroutines*perGoroutine {
+		t.Errorf("GetErrors = %d, want %d", got, goroutines*perGoroutine)
+	}
+}
+
+func TestCounterReset(t *testing.T) {
+	counter := &Counter{}
+	counter.IncrementSucceeded(3)
+	counter.IncrementErrors(2)
+	counter.IncrementSkipped(1)
+	counter.IncrementRowsInserted(5)
+
+	counter.Reset()
+
+	snap := counter.Snapshot()
+	if snap != (CounterSnapshot{}) {
+		t.Errorf("snapshot after Reset = %+v, want all zeroes", snap)
+	}
+}
@@ -0,0 +1,105 @@
+package util
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DeadLetterEntry is one rejected record, enriched with enough context to
+// diagnose the rejection and re-submit the record after fixing it.
+type DeadLetterEntry struct {
+	Timestamp   string                 `json:"timestamp"`
+	SourceFile  string                 `json:"sourceFile"`
+	RecordIndex int                    `json:"recordIndex"`
+	Reason      string                 `json:"reason"`
+	Error       string                 `json:"error"`
+	Record      map[string]interface{} `json:"record"`
+}
+
+// Dead-letter reason codes shared by the loader and transposer stages.
+const (
+	DeadLetterReasonValidation = "validation"
+	DeadLetterReasonTransform  = "transform"
+	DeadLetterReasonCoercion   = "coercion"
+	DeadLetterReasonInsert     = "insert"
+)
+
+// DeadLetterWriter appends rejected records to an NDJSON file so they can be
+// inspected and re-submitted instead of reconstructed from logs. Each entry is
+// a single O_APPEND write, safe for concurrent workers.
+type DeadLetterWriter struct {
+	mu     sync.Mutex
+	path   string
+	source string
+	count  int
+	Logger *zap.Logger
+}
+
+// NewDeadLetterWriter creates a DeadLetterWriter targeting the given path.
+// source is the input file stamped on entries that do not set their own. An
+// empty path yields a disabled writer whose methods are no-ops.
+func NewDeadLetterWriter(path, source string, logger *zap.Logger) *DeadLetterWriter {
+	return &DeadLetterWriter{path: path, source: source, Logger: logger}
+}
+
+// Path returns the rejects file location.
+func (w *DeadLetterWriter) Path() string {
+	if w == nil {
+		return ""
+	}
+	return w.path
+}
+
+// Count reports how many rejected records have been written.
+func (w *DeadLetterWriter) Count() int {
+	if w == nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count
+}
+
+// Record stamps the entry with a timestamp and appends it to the rejects file.
+// Failures to write are logged but never fail the run itself.
+func (w *DeadLetterWriter) Record(entry DeadLetterEntry) {
+	if w == nil || w.path == "" {
+		return
+	}
+
+	entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	if entry.SourceFile == "" {
+		entry.SourceFile = w.source
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		w.Logger.Error("Failed to open dead-letter file",
+			zap.String("deadLetterFile", w.path),
+			zap.Error(err))
+		return
+	}
+	defer file.Close()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		w.Logger.Error("Failed to marshal dead-letter entry",
+			zap.String("deadLetterFile", w.path),
+			zap.Error(err))
+		return
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		w.Logger.Error("Failed to append dead-letter entry",
+			zap.String("deadLetterFile", w.path),
+			zap.Error(err))
+		return
+	}
+	w.count++
+}
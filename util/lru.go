@@ -0,0 +1,101 @@
+package util
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LRUCache is a bounded map of string keys to optional values with
+// least-recently-used eviction. It backs record deduplication (keys only, via
+// Add) and enrichment lookup caching (key/value, via Put and Get), where an
+// unbounded seen-set or result map would grow with the input file. Safe for
+// concurrent use.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+// lruEntry is the list element payload, carrying the key for eviction and the
+// optional cached value.
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+// NewLRUCache builds a cache holding at most capacity keys. A capacity of zero
+// or less falls back to a single entry so the cache still deduplicates
+// back-to-back repeats.
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &LRUCache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Add records the key as most recently used and reports whether it was already
+// present, evicting the least recently used key when the cache is full.
+func (c *LRUCache) Add(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return true
+	}
+
+	c.insert(key, nil)
+	return false
+}
+
+// Put stores the value under the key as most recently used, evicting the least
+// recently used key when the cache is full.
+func (c *LRUCache) Put(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruEntry).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.insert(key, value)
+}
+
+// Get returns the value stored under the key and whether it was present,
+// marking the key as most recently used on a hit.
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*lruEntry).value, true
+}
+
+// insert adds a new entry and enforces the capacity bound. Callers must hold the
+// mutex.
+func (c *LRUCache) insert(key string, value interface{}) {
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len reports how many keys the cache currently holds.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,127 @@
+package util
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ManifestEntry is one processed-file record in the run manifest, giving a durable
+// answer to "what happened to this file" across multi-file and watch-mode runs.
+type ManifestEntry struct {
+	RunID             string  `json:"runId"`
+	Timestamp         string  `json:"timestamp"`
+	InputFile         string  `json:"inputFile"`
+	SizeBytes         int64   `json:"sizeBytes"`
+	Checksum          string  `json:"checksum,omitempty"`
+	ChecksumAlgorithm string  `json:"checksumAlgorithm,omitempty"`
+	RecordsSucceeded  int     `json:"recordsSucceeded"`
+	RecordsErrored    int     `json:"recordsErrored"`
+	RecordsFiltered   int     `json:"recordsFiltered"`
+	DurationSeconds   float64 `json:"durationSeconds"`
+	ArchivedPath      string  `json:"archivedPath,omitempty"`
+	Status            string  `json:"status"`
+	Error             string  `json:"error,omitempty"`
+}
+
+// ManifestWriter appends per-file manifest entries to a JSON Lines file. Each entry
+// is written as a single O_APPEND write, which the OS applies atomically, so entries
+// from concurrent runs interleave without corrupting each other.
+type ManifestWriter struct {
+	mu     sync.Mutex
+	path   string
+	runID  string
+	Logger *zap.Logger
+}
+
+// NewManifestWriter creates a ManifestWriter targeting the given path, minting a
+// run ID shared by every entry this process writes. An empty path yields a disabled
+// writer whose methods are no-ops.
+func NewManifestWriter(path string, logger *zap.Logger) *ManifestWriter {
+	return &ManifestWriter{
+		path:   path,
+		runID:  fmt.Sprintf("%s-%d", time.Now().UTC().Format("20060102T150405"), os.Getpid()),
+		Logger: logger,
+	}
+}
+
+// RunID returns the identifier stamped on every entry this writer produces.
+func (m *ManifestWriter) RunID() string {
+	if m == nil {
+		return ""
+	}
+	return m.runID
+}
+
+// Record stamps the entry with the run ID and timestamp and appends it to the
+// manifest. Failures to write are logged but never fail the run itself.
+func (m *ManifestWriter) Record(entry ManifestEntry) {
+	if m == nil || m.path == "" {
+		return
+	}
+
+	entry.RunID = m.runID
+	entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
+
+	if err := m.append(entry); err != nil {
+		m.Logger.Error("Failed to write manifest entry",
+			zap.String("manifestFile", m.path),
+			zap.Error(err))
+	}
+}
+
+// append serializes the entry and appends it as one line in a single write.
+func (m *ManifestWriter) append(entry ManifestEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open manifest file: %w", err)
+	}
+	defer file.Close()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest entry: %w", err)
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append manifest entry: %w", err)
+	}
+	return nil
+}
+
+// ReadRecentManifestEntries returns the last n entries from the manifest, oldest
+// first, for the -show-manifest query path. Lines that fail to parse are skipped
+// so one corrupt line cannot hide the rest of the history.
+func ReadRecentManifestEntries(path string, n int) ([]ManifestEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []ManifestEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry ManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest file: %w", err)
+	}
+
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
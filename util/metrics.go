@@ -0,0 +1,97 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PipelineMetrics holds process-wide pipeline gauges that do not belong on the
+// per-run Counter: transaction outcomes and worker activity. All fields are updated
+// atomically so the metrics endpoint can read them without locking the pipeline.
+type PipelineMetrics struct {
+	batchesCommitted  int64
+	batchesRolledBack int64
+	workersInFlight   int64
+}
+
+// Metrics is the shared PipelineMetrics instance updated by the mapreduce and
+// dbtransposer layers and exposed by the metrics endpoint.
+var Metrics = &PipelineMetrics{}
+
+// BatchCommitted records a successfully committed worker transaction.
+func (m *PipelineMetrics) BatchCommitted() { atomic.AddInt64(&m.batchesCommitted, 1) }
+
+// BatchRolledBack records a rolled-back worker transaction.
+func (m *PipelineMetrics) BatchRolledBack() { atomic.AddInt64(&m.batchesRolledBack, 1) }
+
+// WorkerStarted increments the in-flight worker gauge.
+func (m *PipelineMetrics) WorkerStarted() { atomic.AddInt64(&m.workersInFlight, 1) }
+
+// WorkerFinished decrements the in-flight worker gauge.
+func (m *PipelineMetrics) WorkerFinished() { atomic.AddInt64(&m.workersInFlight, -1) }
+
+// MetricsServer serves pipeline counters in the Prometheus text exposition format so
+// long-running ingests can be observed live. It is entirely optional; when no address
+// is configured nothing is started.
+type MetricsServer struct {
+	server *http.Server
+	logger *zap.Logger
+}
+
+// StartMetricsServer starts an HTTP server on addr exposing /metrics backed by the
+// given Counter and the shared PipelineMetrics. The server runs until Shutdown.
+func StartMetricsServer(addr string, counter *Counter, logger *zap.Logger) *MetricsServer {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		snapshot := counter.Snapshot()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprintf(w, "# HELP data_ingestor_records_succeeded_total Records inserted successfully.\n")
+		fmt.Fprintf(w, "# TYPE data_ingestor_records_succeeded_total counter\n")
+		fmt.Fprintf(w, "data_ingestor_records_succeeded_total %d\n", snapshot.Succeeded)
+		fmt.Fprintf(w, "# HELP data_ingestor_records_errored_total Records that failed to insert.\n")
+		fmt.Fprintf(w, "# TYPE data_ingestor_records_errored_total counter\n")
+		fmt.Fprintf(w, "data_ingestor_records_errored_total %d\n", snapshot.Errors)
+		fmt.Fprintf(w, "# HELP data_ingestor_records_filtered_total Records dropped by filter expressions.\n")
+		fmt.Fprintf(w, "# TYPE data_ingestor_records_filtered_total counter\n")
+		fmt.Fprintf(w, "data_ingestor_records_filtered_total %d\n", snapshot.Filtered)
+		fmt.Fprintf(w, "# HELP data_ingestor_records_skipped_total Duplicate records skipped by ON CONFLICT DO NOTHING.\n")
+		fmt.Fprintf(w, "# TYPE data_ingestor_records_skipped_total counter\n")
+		fmt.Fprintf(w, "data_ingestor_records_skipped_total %d\n", snapshot.Skipped)
+		fmt.Fprintf(w, "# HELP data_ingestor_batches_committed_total Worker transactions committed.\n")
+		fmt.Fprintf(w, "# TYPE data_ingestor_batches_committed_total counter\n")
+		fmt.Fprintf(w, "data_ingestor_batches_committed_total %d\n", atomic.LoadInt64(&Metrics.batchesCommitted))
+		fmt.Fprintf(w, "# HELP data_ingestor_batches_rolled_back_total Worker transactions rolled back.\n")
+		fmt.Fprintf(w, "# TYPE data_ingestor_batches_rolled_back_total counter\n")
+		fmt.Fprintf(w, "data_ingestor_batches_rolled_back_total %d\n", atomic.LoadInt64(&Metrics.batchesRolledBack))
+		fmt.Fprintf(w, "# HELP data_ingestor_workers_in_flight Workers currently processing records.\n")
+		fmt.Fprintf(w, "# TYPE data_ingestor_workers_in_flight gauge\n")
+		fmt.Fprintf(w, "data_ingestor_workers_in_flight %d\n", atomic.LoadInt64(&Metrics.workersInFlight))
+	})
+
+	ms := &MetricsServer{
+		server: &http.Server{Addr: addr, Handler: mux},
+		logger: logger,
+	}
+
+	go func() {
+		logger.Info("Metrics endpoint listening", zap.String("addr", addr))
+		if err := ms.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Metrics endpoint failed", zap.String("addr", addr), zap.Error(err))
+		}
+	}()
+	return ms
+}
+
+// Shutdown stops the metrics server gracefully, waiting briefly for in-flight scrapes.
+func (ms *MetricsServer) Shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ms.server.Shutdown(ctx); err != nil {
+		ms.logger.Error("Failed to shut down metrics endpoint", zap.Error(err))
+	}
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token-bucket limiter shared across all workers to cap the
+// aggregate insert rate against the database. Tokens accrue continuously at
+// ratePerSec up to a one-second burst; each record consumes one token, and
+// callers block until a token is available.
+//
+// Hand-rolled rather than pulling in golang.org/x/time/rate because this is the
+// only place we need one and the semantics are a strict subset.
+type RateLimiter struct {
+	mu         sync.Mutex
+	ratePerSec float64   // Tokens added per second
+	burst      float64   // Maximum tokens the bucket holds
+	tokens     float64   // Tokens currently available
+	last       time.Time // When tokens was last refilled
+}
+
+// NewRateLimiter builds a limiter allowing ratePerSec operations per second,
+// with a burst of up to one second's worth of tokens. Returns nil when
+// ratePerSec <= 0, which callers treat as "unlimited".
+//
+// Parameters:
+//   - ratePerSec: Sustained operations per second allowed; <= 0 disables limiting.
+// Returns:
+//   - *RateLimiter: The shared limiter, or nil when limiting is disabled.
+func NewRateLimiter(ratePerSec float64) *RateLimiter {
+	if ratePerSec <= 0 {
+		return nil
+	}
+	burst := ratePerSec
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		ratePerSec: ratePerSec,
+		burst:      burst,
+		tokens:     burst,
+		last:       time.Now(),
+	}
+}
+
+// Wait blocks until a token is available and consumes it. A nil receiver is a
+// no-op so callers can pass the limiter through unconditionally.
+func (r *RateLimiter) Wait() {
+	if r == nil {
+		return
+	}
+	for {
+		r.mu.Lock()
+		now := time.Now()
+		r.tokens += now.Sub(r.last).Seconds() * r.ratePerSec
+		if r.tokens > r.burst {
+			r.tokens = r.burst
+		}
+		r.last = now
+		if r.tokens >= 1 {
+			r.tokens--
+			r.mu.Unlock()
+			return
+		}
+		// Sleep just long enough for the deficit to refill, then re-check; other
+		// waiters may have consumed the token in the meantime.
+		wait := time.Duration((1 - r.tokens) / r.ratePerSec * float64(time.Second))
+		r.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
@@ -0,0 +1,74 @@
+package util
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRateLimiterCapsSustainedRate times 3*N Waits against a rate of N/s. The
+// bucket starts with a full one-second burst, so the first N calls are free and
+// the remaining 2*N must wait roughly two seconds.
+func TestRateLimiterCapsSustainedRate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test; skipped with -short")
+	}
+	const rate = 50
+	limiter := NewRateLimiter(rate)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 3*rate/4; i++ {
+				limiter.Wait()
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	// 2*N paced calls at N/s: at least ~2s, with generous slack on the upper
+	// bound for slow CI machines.
+	if elapsed < 1800*time.Millisecond {
+		t.Errorf("3*N waits at N/s finished in %v, want at least ~2s", elapsed)
+	}
+	if elapsed > 6*time.Second {
+		t.Errorf("3*N waits at N/s took %v, limiter is pacing far too slowly", elapsed)
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	if limiter := NewRateLimiter(0); limiter != nil {
+		t.Errorf("NewRateLimiter(0) = %v, want nil", limiter)
+	}
+	if limiter := NewRateLimiter(-5); limiter != nil {
+		t.Errorf("NewRateLimiter(-5) = %v, want nil", limiter)
+	}
+
+	// A nil limiter's Wait is a no-op, not a crash or a block
+	var limiter *RateLimiter
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			limiter.Wait()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("nil limiter's Wait blocked")
+	}
+}
+
+func TestRateLimiterFractionalRateKeepsMinimumBurst(t *testing.T) {
+	limiter := NewRateLimiter(0.5)
+	start := time.Now()
+	limiter.Wait() // consumes the single burst token immediately
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("first Wait at a fractional rate took %v, want it served from the burst", elapsed)
+	}
+}
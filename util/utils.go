@@ -4,13 +4,72 @@ import (
 	"data-ingestor/models"
 	"encoding/xml"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 type Counter struct {
 	mu    sync.Mutex
 	totalSucceeded int
 	totalErrors int
+	totalFiltered int
+	totalSkipped int
+	totalDeleted int
+	totalRowsInserted int
+
+	// startTime anchors overall rate computation; windowTime/windowTotal track the
+	// state at the previous WindowRate call for the moving-window rate.
+	startTime   time.Time
+	windowTime  time.Time
+	windowTotal int
+}
+
+// StartTiming marks the beginning of a run for throughput computation. Also resets the
+// moving window.
+func (c *Counter) StartTiming() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.startTime = now
+	c.windowTime = now
+	c.windowTotal = c.totalSucceeded + c.totalErrors
+}
+
+// OverallRate returns processed records (succeeded + errored) per second since
+// StartTiming. Zero when timing has not been started.
+func (c *Counter) OverallRate() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.startTime.IsZero() {
+		return 0
+	}
+	elapsed := time.Since(c.startTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(c.totalSucceeded+c.totalErrors) / elapsed
+}
+
+// WindowRate returns processed records per second since the previous WindowRate call
+// (or StartTiming), giving a moving-window view of current throughput.
+func (c *Counter) WindowRate() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.windowTime.IsZero() {
+		return 0
+	}
+	now := time.Now()
+	elapsed := now.Sub(c.windowTime).Seconds()
+	total := c.totalSucceeded + c.totalErrors
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(total-c.windowTotal) / elapsed
+	}
+	c.windowTime = now
+	c.windowTotal = total
+	return rate
 }
 
 // IncrementSucceeded safely increments the total count by the given value.
@@ -39,6 +98,141 @@ func (c *Counter) GetErrors() int {
 	return c.totalErrors
 }
 
+// IncrementFiltered counts records dropped by filter expressions at parse time.
+func (c *Counter) IncrementFiltered(count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.totalFiltered += count
+}
+
+func (c *Counter) GetFiltered() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.totalFiltered
+}
+
+// IncrementSkipped counts rows silently skipped by ON CONFLICT DO NOTHING so duplicate
+// deliveries are visible in the summary rather than hidden inside the success count.
+func (c *Counter) IncrementSkipped(count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.totalSkipped += count
+}
+
+func (c *Counter) GetSkipped() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.totalSkipped
+}
+
+// IncrementDeleted counts soft-delete tombstone records that removed an existing
+// row, so deletes are visible separately from inserts in the summary.
+func (c *Counter) IncrementDeleted(count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.totalDeleted += count
+}
+
+func (c *Counter) GetDeleted() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.totalDeleted
+}
+
+// IncrementRowsInserted counts rows actually written to the database (from
+// sql.Result.RowsAffected), which can differ from the succeeded record count when a
+// record flattens into multiple rows or an upsert DOES NOTHING.
+func (c *Counter) IncrementRowsInserted(count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.totalRowsInserted += count
+}
+
+func (c *Counter) GetRowsInserted() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.totalRowsInserted
+}
+
+
+// CounterSnapshot is a consistent point-in-time read of a Counter's totals, taken
+// under a single lock so the values always belong together.
+type CounterSnapshot struct {
+	Succeeded    int
+	Errors       int
+	Filtered     int
+	Skipped      int
+	Deleted      int
+	RowsInserted int
+}
+
+// Snapshot atomically reads all totals together. Use this instead of separate getters
+// when the values are reported side by side (e.g. per-file summaries).
+func (c *Counter) Snapshot() CounterSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CounterSnapshot{
+		Succeeded:    c.totalSucceeded,
+		Errors:       c.totalErrors,
+		Filtered:     c.totalFiltered,
+		Skipped:      c.totalSkipped,
+		Deleted:      c.totalDeleted,
+		RowsInserted: c.totalRowsInserted,
+	}
+}
+
+// Reset zeroes all totals, allowing one Counter to be reused across files in a
+// multi-file run.
+func (c *Counter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.totalSucceeded = 0
+	c.totalErrors = 0
+	c.totalFiltered = 0
+	c.totalSkipped = 0
+	c.totalDeleted = 0
+	c.totalRowsInserted = 0
+}
+
+// ParseByteSize parses a human-readable byte size ("500MB", "2GB", "512KB", or a
+// bare number of bytes) into bytes. Units are powers of 1024 and case-insensitive;
+// fractional values like "1.5GB" are accepted.
+//
+// Parameters:
+//   - s: The size string to parse.
+// Returns:
+//   - int64: The size in bytes.
+//   - error: An error when the string is empty, negative, or not a recognized size.
+func ParseByteSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	if str == "" {
+		return 0, fmt.Errorf("empty byte size")
+	}
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		mult   int64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(str, unit.suffix) {
+			multiplier = unit.mult
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
 
 // GenerateSampleRecords Generate sample records (replace with actual data source)
 func GenerateSampleRecords(count int) []models.Record {